@@ -3,11 +3,13 @@ package main
 import (
 	"context"
 	"fmt"
+	"maps"
 	"os"
 	"os/signal"
 	"sync"
 	"syscall"
 
+	"github.com/twmb/franz-go/pkg/kadm"
 	"github.com/twmb/franz-go/pkg/kgo"
 
 	"github.com/jarkkom/kafkadog/internal/config"
@@ -22,24 +24,109 @@ func main() {
 		os.Exit(1)
 	}
 
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Handle termination signals
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	var wg sync.WaitGroup
+
+	go func() {
+		<-sigChan
+		fmt.Fprintln(os.Stderr, "Received termination signal. Shutting down...")
+		cancel()
+	}()
+
 	opts := []kgo.Opt{
 		kgo.SeedBrokers(cfg.Brokers...),
 	}
 
-	if cfg.ConsumeMode {
+	tlsConfig, err := cfg.TLSConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if tlsConfig != nil {
+		opts = append(opts, kgo.DialTLSConfig(tlsConfig))
+	}
+
+	mechanism, err := cfg.SASLMechanism()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if mechanism != nil {
+		opts = append(opts, kgo.SASL(mechanism))
+	}
+
+	var bounds *consumer.Bounds
+	if cfg.ConsumeMode && cfg.Bounded() {
+		// Resolve exact offset ranges up front so consumption can stop at their end
+		bounds, err = resolveBounds(ctx, opts, cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error resolving consumer bounds: %v\n", err)
+			os.Exit(1)
+		}
+		opts = append(opts,
+			kgo.ConsumePartitions(bounds.StartOffsets()),
+			// Transaction markers advance the position towards the end offset
+			kgo.KeepControlRecords(),
+		)
+	} else if cfg.ConsumeMode {
 		// Get the consumer offset from configuration
 		kafkaOffset, err := cfg.CreateConsumerOffset()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: %v, defaulting to end of topic\n", err)
-			kafkaOffset = kgo.NewOffset().AtEnd()
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
 		}
 
-		// Set options for direct topic consumption without joining a consumer group
-		opts = append(opts,
-			kgo.ConsumeTopics(cfg.Topic),
-			kgo.ConsumeResetOffset(kafkaOffset),
-			kgo.ConsumerGroup(""), // Empty group ID prevents joining a consumer group
-		)
+		partitionOffsets, err := cfg.CreatePartitionOffsets()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if cfg.TopicRegex {
+			// Topics are matched as regular expressions, including topics created later
+			opts = append(opts, kgo.ConsumeRegex())
+		}
+
+		if cfg.Group != "" {
+			balancer, err := cfg.CreateBalancer()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			// Join the consumer group, resuming from committed offsets
+			opts = append(opts,
+				kgo.ConsumerGroup(cfg.Group),
+				kgo.ConsumeTopics(cfg.Topics...),
+				kgo.ConsumeResetOffset(kafkaOffset),
+				kgo.Balancers(balancer),
+			)
+			if cfg.CommitMode == config.CommitManual {
+				opts = append(opts, kgo.DisableAutoCommit())
+			} else {
+				// Only commit records once they have been printed
+				opts = append(opts, kgo.AutoCommitMarks())
+			}
+		} else if partitionOffsets != nil {
+			// Consume only the selected partitions of each topic, each from its own offset
+			topicOffsets := make(map[string]map[int32]kgo.Offset, len(cfg.Topics))
+			for _, topic := range cfg.Topics {
+				topicOffsets[topic] = maps.Clone(partitionOffsets)
+			}
+			opts = append(opts, kgo.ConsumePartitions(topicOffsets))
+		} else {
+			// Set options for direct topic consumption without joining a consumer group
+			opts = append(opts,
+				kgo.ConsumeTopics(cfg.Topics...),
+				kgo.ConsumeResetOffset(kafkaOffset),
+				kgo.ConsumerGroup(""), // Empty group ID prevents joining a consumer group
+			)
+		}
 	}
 
 	client, err := kgo.NewClient(opts...)
@@ -49,23 +136,9 @@ func main() {
 	}
 	defer client.Close()
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	// Handle termination signals
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-	var wg sync.WaitGroup
-
-	go func() {
-		<-sigChan
-		fmt.Fprintln(os.Stderr, "Received termination signal. Shutting down...")
-		cancel()
-	}()
-
 	if cfg.ProduceMode {
 		wg.Add(1)
-		prod, err := producer.New(client, cfg.Topic, cfg.Format)
+		prod, err := producer.New(client, cfg)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error creating producer: %v\n", err)
 			os.Exit(1)
@@ -75,7 +148,7 @@ func main() {
 
 	if cfg.ConsumeMode {
 		wg.Add(1)
-		cons, err := consumer.New(client, cfg.Format, cfg.MessageCount, cfg.ProtoImportDirs, cfg.MessageType)
+		cons, err := consumer.New(client, cfg, bounds)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error creating consumer: %v\n", err)
 			os.Exit(1)
@@ -85,3 +158,15 @@ func main() {
 
 	wg.Wait()
 }
+
+// resolveBounds lists partition offsets with a short-lived client and resolves
+// the offset range to consume from each partition
+func resolveBounds(ctx context.Context, opts []kgo.Opt, cfg *config.Config) (*consumer.Bounds, error) {
+	client, err := kgo.NewClient(opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	return consumer.ResolveBounds(ctx, kadm.NewClient(client), cfg)
+}
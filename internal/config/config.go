@@ -3,6 +3,9 @@ package config
 import (
 	"flag"
 	"fmt"
+	"os"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -13,52 +16,176 @@ import (
 // Format represents the format for displaying/inputting messages
 type Format string
 
+// stringList is a flag.Value collecting the values of a repeatable flag
+type stringList []string
+
+// String returns the collected values separated by commas
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+// Set appends a value each time the flag is given
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// Commit modes for consumer group offsets
+const (
+	CommitAuto   = "auto"   // Periodically commit the offsets of printed records
+	CommitManual = "manual" // Synchronously commit after each printed record
+)
+
 // Config holds application configuration
 type Config struct {
 	Brokers     []string
-	Topic       string
+	TLS         TLSOptions  // Encryption settings for broker connections
+	SASL        SASLOptions // Authentication settings for broker connections
+	Topics      []string    // Topics to consume from, or the single topic to produce to (-t flag)
+	TopicRegex  bool        // Topics are regular expressions matching topics to consume (-regex flag)
 	ProduceMode bool
 	ConsumeMode bool
-	Format      Format
+	Format      Format // Default format for keys and values (-f flag)
+	KeyFormat   Format // Format for record keys (-fk flag, defaults to Format)
+	ValueFormat Format // Format for record values (-fv flag, defaults to Format)
 	// DecodeProtobuf removed - use Format == "protobuf" instead
-	MessageCount    int      // Number of messages to read in consumer mode, 0 for unlimited
-	ConsumerOffset  string   // Controls where to start consuming from: "beginning", "end", or an offset value
-	ProtoImportDirs []string // Directories to search for .proto files (-I flag)
-	MessageType     string   // Message type for protobuf schema decoding (-M flag)
+	MessageCount    int                // Number of messages to read in consumer mode, 0 for unlimited
+	ConsumerOffset  string             // Controls where to start consuming from: "beginning", "end", an offset value, a timestamp, or partition:offset entries
+	Partitions      []int32            // Partitions to consume from (-p flag), empty for all
+	ExitAtEnd       bool               // Stop consuming at the end of each partition (-e flag)
+	EndOffset       string             // Offset or timestamp to stop consuming before (-u flag)
+	Group           string             // Consumer group to join (-G flag), empty for direct consumption
+	Balancer        string             // Group partition assignment strategy (-balancer flag)
+	CommitMode      string             // How group offsets are committed: CommitAuto or CommitManual (-commit flag)
+	ProtoImportDirs []string           // Directories to search for .proto files (-I flag)
+	DescriptorSets  []string           // Compiled FileDescriptorSet files (-descriptor-set flag)
+	MessageType     string             // Message type for protobuf schema decoding (-M flag)
+	RegistryURL     string             // Schema Registry for values in its wire format (-r flag)
+	AvroSchemaFile  string             // Avro schema file for the avro format (-avsc flag)
+	JSONSchemaFile  string             // JSON Schema file validating json values (-json-schema flag)
+	Compression     string             // Compression of payloads inside record values (-z flag)
+	OutputFormat    string             // kcat-style output format string for consumed records (-F flag)
+	JSONMode        bool               // Consume and produce records as JSON envelopes (-J flag)
+	KeyDelimiter    string             // Delimiter splitting key and value on producer input lines (-K flag)
+	HeaderFormat    Format             // Default format for header values (-fh flag)
+	HeaderFormats   map[string]Format  // Per-header-name format overrides (-fh flag)
+	Headers         []kgo.RecordHeader // Headers added to every produced record (-H flag)
 }
 
 // Parse processes command line arguments and returns a Config
 func Parse() (*Config, error) {
 	var (
 		brokers     string
-		topic       string
+		topics      stringList
+		topicRegex  bool
 		format      string
+		keyFormat   string
+		valueFormat string
 		produceMode bool
 		consumeMode bool
 		// decodeProtobuf removed - use format == "protobuf" instead
-		messageCount    int    // Number of messages to read in consumer mode
-		consumerOffset  string // New variable for consumer offset flag
-		protoImportDirs string // Comma-separated list of proto import directories
-		messageType     string // Message type for protobuf schema decoding
+		messageCount         int        // Number of messages to read in consumer mode
+		consumerOffset       string     // New variable for consumer offset flag
+		partitions           string     // Comma-separated list of partitions to consume
+		exitAtEnd            bool       // Stop at the end of each partition
+		endOffset            string     // Offset or timestamp to stop consuming before
+		group                string     // Consumer group to join
+		balancer             string     // Group partition assignment strategy
+		commitMode           string     // Group offset commit mode
+		protoImportDirs      string     // Comma-separated list of proto import directories
+		descriptorSets       stringList // Compiled FileDescriptorSet files
+		messageType          string     // Message type for protobuf schema decoding
+		registryURL          string     // Schema Registry URL
+		avroSchemaFile       string     // Avro schema file
+		jsonSchemaFile       string     // JSON Schema file
+		compression          string     // Compression of payloads inside record values
+		outputFormat         string     // kcat-style output format string
+		jsonMode             bool       // Consume and produce records as JSON envelopes
+		keyDelimiter         string     // Delimiter between key and value in producer input
+		headerFormats        string     // Header formats, e.g. "hex,traceparent=raw"
+		headers              stringList
+		tlsOpts              TLSOptions  // TLS connection settings
+		saslOpts             SASLOptions // SASL authentication settings
+		passwordFile         string      // File containing the SASL password
+		registryPasswordFile string      // File containing the Schema Registry password
+		configPath           string      // Config file to read profiles from
+		profile              string      // Config file profile to apply
 	)
 
 	availableFormats := ff.GetAvailableFormats()
-	formatUsage := fmt.Sprintf("Output format: %s", strings.Join(availableFormats, ", "))
+	formatUsage := fmt.Sprintf("Output format, or a comma-separated pipeline of formats with the outermost layer first (e.g. 'base64,gzip,protobuf'), each optionally followed by options (e.g. 'hex:upper,group=2'): %s", strings.Join(availableFormats, ", "))
 
+	flag.StringVar(&configPath, "config", "", "Config file with named profiles (default: $"+ConfigFileEnv+" or ~/.config/kafkadog/config.yaml)")
+	flag.StringVar(&profile, "X", "", "Config file profile to use for options not given as flags (default: $"+ProfileEnv+" or the file's default profile)")
 	flag.StringVar(&brokers, "b", "localhost:9092", "Kafka broker(s) separated by commas")
-	flag.StringVar(&topic, "t", "", "Topic to produce to or consume from")
+	flag.BoolVar(&tlsOpts.Enabled, "tls", false, "Connect to brokers over TLS (implied by the other -tls-* flags)")
+	flag.StringVar(&tlsOpts.CAFile, "tls-ca", "", "PEM file of CA certificates to verify brokers with (default: system CAs)")
+	flag.StringVar(&tlsOpts.CertFile, "tls-cert", "", "PEM client certificate for mutual TLS")
+	flag.StringVar(&tlsOpts.KeyFile, "tls-key", "", "PEM private key of the client certificate (-tls-cert)")
+	flag.StringVar(&tlsOpts.ServerName, "tls-server-name", "", "Server name to verify broker certificates against (default: broker host)")
+	flag.BoolVar(&tlsOpts.Insecure, "tls-insecure", false, "Skip broker certificate verification (insecure, for testing only)")
+	flag.StringVar(&saslOpts.Mechanism, "sasl", "", "SASL mechanism: PLAIN, SCRAM-SHA-256, SCRAM-SHA-512, OAUTHBEARER")
+	flag.StringVar(&saslOpts.Username, "sasl-user", "", "SASL username for PLAIN and SCRAM")
+	flag.StringVar(&passwordFile, "sasl-password-file", "", "File containing the SASL password for PLAIN and SCRAM (default: $"+SASLPasswordEnv+")")
+	flag.StringVar(&saslOpts.TokenCommand, "sasl-token-cmd", "", "Shell command printing an OAUTHBEARER token, run for each broker connection")
+	flag.Var(&topics, "t", "Topic to produce to or consume from; consumer mode accepts several, comma-separated or repeated")
+	flag.BoolVar(&topicRegex, "regex", false, "Treat consumer topics (-t) as regular expressions, e.g. 'orders\\..*'; repeat -t for several, as commas are part of the expression")
 	flag.StringVar(&format, "f", "raw", formatUsage)
+	flag.StringVar(&keyFormat, "fk", "", "Format for record keys, overrides -f for keys")
+	flag.StringVar(&valueFormat, "fv", "", "Format for record values, overrides -f for values")
+	flag.StringVar(&headerFormats, "fh", "raw", "Format for header values: a default format and/or comma-separated name=format overrides, e.g. 'hex,traceparent=raw' (join pipeline stages and codec options with '+', e.g. 'payload=base64+gzip+json')")
 	flag.BoolVar(&produceMode, "P", false, "Producer mode - read from stdin and send to Kafka")
 	flag.BoolVar(&consumeMode, "C", false, "Consumer mode - read from Kafka and write to stdout")
 	// -proto flag removed - use -f protobuf instead
 	flag.IntVar(&messageCount, "c", 0, "Number of messages to read in consumer mode (0 for unlimited)")
 	flag.StringVar(&protoImportDirs, "I", "", "Comma-separated list of directories to search for .proto files")
+	flag.Var(&descriptorSets, "descriptor-set", "FileDescriptorSet file to load protobuf schemas from, e.g. from protoc --descriptor_set_out or buf build (comma-separated or repeated)")
 	flag.StringVar(&messageType, "M", "", "Message type for protobuf schema decoding (e.g. 'package.MessageName')")
-	flag.StringVar(&consumerOffset, "o", "end", "Consumer offset - where to start consuming from: 'beginning', 'end', or an offset value")
+	flag.StringVar(&registryURL, "r", "", "Schema Registry URL for values in the Confluent wire format, e.g. 'http://user@localhost:8081'")
+	flag.StringVar(&registryPasswordFile, "r-password-file", "", "File containing the password for the user in the Schema Registry URL (-r) (default: $"+SchemaRegistryPasswordEnv+")")
+	flag.StringVar(&avroSchemaFile, "avsc", "", "Avro schema file (.avsc) for the avro format, when not using a Schema Registry (-r)")
+	flag.StringVar(&jsonSchemaFile, "json-schema", "", "JSON Schema file validating values in the json formats: violations are reported when consuming and rejected when producing")
+	flag.StringVar(&compression, "z", ff.CompressionNone, "Compression of payloads inside record values, independent of Kafka batch compression: "+strings.Join(ff.Compressions, ", ")+" ('auto' detects it when consuming)")
+	flag.StringVar(&outputFormat, "F", "", "Output format string for consumed records, e.g. '%t [%p] at %o: %k=%s\\n' (default: value only)")
+	flag.BoolVar(&jsonMode, "J", false, "JSON mode - consumer prints each record as a JSON object, producer reads one JSON object with key, value and headers per line")
+	flag.Var(&headers, "H", "Header to add to produced records as key=value (repeatable)")
+	flag.StringVar(&keyDelimiter, "K", "", "Key delimiter in producer mode - each input line is split into key and value at the first occurrence")
+	flag.StringVar(&consumerOffset, "o", "end", "Consumer offset - where to start consuming from: 'beginning', 'end', an offset value, a timestamp (s@<unix millis> or RFC3339) or a negative duration (e.g. -1h), optionally per partition as 'partition:offset,...'")
+	flag.BoolVar(&exitAtEnd, "e", false, "Exit when the end of each partition, as of startup, has been reached")
+	flag.StringVar(&endOffset, "u", "", "Stop consuming before this offset or timestamp (same syntax as -o) in each partition")
+	flag.StringVar(&partitions, "p", "", "Comma-separated list of partitions to consume from (default: all)")
+	flag.StringVar(&group, "G", "", "Consumer group to join - offsets are committed and consumption resumes from them, -o applies when the group has no committed offset")
+	flag.StringVar(&balancer, "balancer", "cooperative-sticky", "Group partition assignment strategy: cooperative-sticky, sticky, range, roundrobin")
+	flag.StringVar(&commitMode, "commit", CommitAuto, "Group offset commit mode: 'auto' commits printed records periodically, 'manual' commits after each printed record")
 
 	flag.Parse()
 
-	if topic == "" {
+	if configPath == "" {
+		configPath = os.Getenv(ConfigFileEnv)
+	}
+	if profile == "" {
+		profile = os.Getenv(ProfileEnv)
+	}
+	// Flags take precedence over environment variables, which take
+	// precedence over the config file profile
+	if err := applyEnv(flag.CommandLine); err != nil {
+		return nil, err
+	}
+	if err := applyProfile(flag.CommandLine, configPath, profile); err != nil {
+		return nil, err
+	}
+
+	topicList := splitList(topics)
+	if topicRegex {
+		// Regular expressions may contain commas, e.g. in {m,n}, so each -t is one
+		topicList = nil
+		for _, topic := range topics {
+			if topic = strings.TrimSpace(topic); topic != "" {
+				topicList = append(topicList, topic)
+			}
+		}
+	}
+	if len(topicList) == 0 {
 		return nil, fmt.Errorf("topic (-t) must be specified")
 	}
 
@@ -71,10 +198,154 @@ func Parse() (*Config, error) {
 		return nil, fmt.Errorf("cannot use both produce (-P) and consume (-C) modes simultaneously")
 	}
 
-	// Validate the format by checking if a codec exists for it
-	_, err := ff.NewCodec(format)
+	if produceMode && (len(topicList) > 1 || topicRegex) {
+		return nil, fmt.Errorf("producer mode (-P) requires exactly one topic (-t) and no -regex")
+	}
+
+	if topicRegex {
+		_, perPartition, _ := splitOffsetSpec(consumerOffset)
+		if partitions != "" || len(perPartition) > 0 || exitAtEnd || endOffset != "" {
+			return nil, fmt.Errorf("topic regexes (-regex) cannot be combined with -p, per-partition offsets, -e or -u")
+		}
+		for _, topic := range topicList {
+			if _, err := regexp.Compile(topic); err != nil {
+				return nil, fmt.Errorf("invalid topic regex (-t) '%s': %w", topic, err)
+			}
+		}
+	}
+
+	if jsonMode && outputFormat != "" {
+		return nil, fmt.Errorf("cannot use both JSON output (-J) and a format string (-F)")
+	}
+
+	if jsonMode && keyDelimiter != "" {
+		return nil, fmt.Errorf("cannot use both JSON input (-J) and a key delimiter (-K)")
+	}
+
+	if len(headers) > 0 && !produceMode {
+		return nil, fmt.Errorf("headers (-H) are only supported in producer mode")
+	}
+
+	if partitions != "" && !consumeMode {
+		return nil, fmt.Errorf("partitions (-p) are only supported in consumer mode")
+	}
+
+	if (exitAtEnd || endOffset != "") && !consumeMode {
+		return nil, fmt.Errorf("end bounds (-e, -u) are only supported in consumer mode")
+	}
+
+	defaultOffset, perPartition, err := splitOffsetSpec(consumerOffset)
+	if err != nil {
+		return nil, fmt.Errorf("invalid offset (-o): %w", err)
+	}
+	if _, err := parseOffset(defaultOffset); err != nil {
+		return nil, fmt.Errorf("invalid offset (-o): %w", err)
+	}
+	for partition, value := range perPartition {
+		if _, err := parseOffset(value); err != nil {
+			return nil, fmt.Errorf("invalid offset (-o) for partition %d: %w", partition, err)
+		}
+	}
+
+	if _, err := parseOffset(endOffset); err != nil {
+		return nil, fmt.Errorf("invalid end offset (-u): %w", err)
+	}
+
+	if group != "" {
+		if !consumeMode {
+			return nil, fmt.Errorf("consumer group (-G) is only supported in consumer mode")
+		}
+		_, perPartition, _ := splitOffsetSpec(consumerOffset)
+		if partitions != "" || len(perPartition) > 0 {
+			return nil, fmt.Errorf("consumer group (-G) assigns partitions itself and cannot be combined with -p or per-partition offsets")
+		}
+		if exitAtEnd || endOffset != "" {
+			return nil, fmt.Errorf("consumer group (-G) cannot be combined with end bounds (-e, -u)")
+		}
+	}
+
+	if (tlsOpts.CertFile == "") != (tlsOpts.KeyFile == "") {
+		return nil, fmt.Errorf("client certificate (-tls-cert) and key (-tls-key) must be given together")
+	}
+
+	// Any TLS setting implies TLS
+	if tlsOpts.CAFile != "" || tlsOpts.CertFile != "" || tlsOpts.ServerName != "" || tlsOpts.Insecure {
+		tlsOpts.Enabled = true
+	}
+
+	saslOpts.Mechanism = strings.ToUpper(saslOpts.Mechanism)
+	if saslOpts.Mechanism != "" && saslOpts.Mechanism != SASLOAuthBearer {
+		password, err := readPassword(passwordFile, SASLPasswordEnv)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read SASL password file: %w", err)
+		}
+		saslOpts.Password = password
+	}
+	if err := validateSASL(saslOpts); err != nil {
+		return nil, err
+	}
+
+	if registryURL != "" {
+		if _, err := ff.NewSchemaRegistry(registryURL); err != nil {
+			return nil, fmt.Errorf("invalid Schema Registry URL (-r): %w", err)
+		}
+		registryURL, err = registryURLWithPassword(registryURL, registryPasswordFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := createBalancer(balancer); err != nil {
+		return nil, fmt.Errorf("invalid balancer (-balancer): %w", err)
+	}
+
+	if commitMode != CommitAuto && commitMode != CommitManual {
+		return nil, fmt.Errorf("invalid commit mode (-commit): %s. Must be one of: %s, %s", commitMode, CommitAuto, CommitManual)
+	}
+
+	if !slices.Contains(ff.Compressions, compression) {
+		return nil, fmt.Errorf("invalid compression (-z): %s. Must be one of: %s", compression, strings.Join(ff.Compressions, ", "))
+	}
+	if compression == ff.CompressionAuto && produceMode {
+		return nil, fmt.Errorf("compression (-z) must be given explicitly in producer mode, '%s' only detects it when consuming", ff.CompressionAuto)
+	}
+
+	if keyDelimiter != "" && !produceMode {
+		return nil, fmt.Errorf("key delimiter (-K) is only supported in producer mode")
+	}
+
+	// Key and value formats default to the general format
+	if keyFormat == "" {
+		keyFormat = format
+	}
+	if valueFormat == "" {
+		valueFormat = format
+	}
+
+	// Validate the formats by checking if a codec exists for each
+	for _, f := range []struct{ flag, value string }{
+		{"-f", format},
+		{"-fk", keyFormat},
+		{"-fv", valueFormat},
+	} {
+		if err := ff.ValidateFormat(f.value); err != nil {
+			return nil, fmt.Errorf("invalid format (%s): %w. Formats are: %s", f.flag, err, strings.Join(availableFormats, ", "))
+		}
+	}
+
+	headerFormat, headerFormatsMap, err := parseHeaderFormats(headerFormats)
 	if err != nil {
-		return nil, fmt.Errorf("invalid format (-f): %s. Must be one of: %s", format, strings.Join(availableFormats, ", "))
+		return nil, fmt.Errorf("invalid header format (-fh): %w", err)
+	}
+
+	recordHeaders, err := parseHeaders(headers)
+	if err != nil {
+		return nil, fmt.Errorf("invalid header (-H): %w", err)
+	}
+
+	partitionList, err := parsePartitions(partitions)
+	if err != nil {
+		return nil, fmt.Errorf("invalid partitions (-p): %w", err)
 	}
 
 	// Parse proto import directories
@@ -87,51 +358,170 @@ func Parse() (*Config, error) {
 		}
 	}
 
-	return &Config{
+	cfg := &Config{
 		Brokers:     strings.Split(brokers, ","),
-		Topic:       topic,
+		TLS:         tlsOpts,
+		SASL:        saslOpts,
+		Topics:      topicList,
+		TopicRegex:  topicRegex,
 		ProduceMode: produceMode,
 		ConsumeMode: consumeMode,
 		Format:      Format(format),
+		KeyFormat:   Format(keyFormat),
+		ValueFormat: Format(valueFormat),
 		// DecodeProtobuf removed - use Format == "protobuf" instead
 		MessageCount:    messageCount,
 		ConsumerOffset:  consumerOffset,
+		Partitions:      partitionList,
+		ExitAtEnd:       exitAtEnd,
+		EndOffset:       endOffset,
+		Group:           group,
+		Balancer:        balancer,
+		CommitMode:      commitMode,
 		ProtoImportDirs: protoImportDirsList,
+		DescriptorSets:  splitList(descriptorSets),
 		MessageType:     messageType,
-	}, nil
+		RegistryURL:     registryURL,
+		AvroSchemaFile:  avroSchemaFile,
+		JSONSchemaFile:  jsonSchemaFile,
+		Compression:     compression,
+		OutputFormat:    outputFormat,
+		JSONMode:        jsonMode,
+		KeyDelimiter:    keyDelimiter,
+		HeaderFormat:    headerFormat,
+		HeaderFormats:   headerFormatsMap,
+		Headers:         recordHeaders,
+	}
+
+	// Checked once the schema flags are parsed
+	if produceMode {
+		if err := ff.ValidateProduceFormat(valueFormat, cfg.SchemaOptions()); err != nil {
+			return nil, fmt.Errorf("invalid format (-fv) in producer mode: %w", err)
+		}
+	}
+
+	return cfg, nil
 }
 
-// CreateConsumerOffset creates a kgo.Offset based on the ConsumerOffset config value
-// It handles "beginning", "end", and numerical offset values (both absolute and relative)
-func (c *Config) CreateConsumerOffset() (kgo.Offset, error) {
-	// Default to consuming from the end of the topic
-	kafkaOffset := kgo.NewOffset().AtEnd()
+// headerPipelineSeparator separates pipeline stages and codec options in
+// header formats (-fh)
+const headerPipelineSeparator = "+"
 
-	// Handle empty string as end
-	if c.ConsumerOffset == "" {
-		return kafkaOffset, nil
-	}
+// parseHeaderFormats parses a header format specification consisting of
+// comma-separated entries that are either a default format or name=format
+func parseHeaderFormats(spec string) (Format, map[string]Format, error) {
+	defaultFormat := Format("raw")
+	overrides := make(map[string]Format)
 
-	switch c.ConsumerOffset {
-	case "beginning":
-		kafkaOffset = kgo.NewOffset().AtStart()
-	case "end":
-		kafkaOffset = kgo.NewOffset().AtEnd()
-	default:
-		// Try to parse as integer
-		offsetVal, err := strconv.ParseInt(c.ConsumerOffset, 10, 64)
-		if err != nil {
-			return kafkaOffset, fmt.Errorf("invalid offset value '%s': %w", c.ConsumerOffset, err)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
 		}
 
-		if offsetVal < 0 {
-			// Negative value means relative offset from end
-			kafkaOffset = kgo.NewOffset().Relative(offsetVal)
+		// A default format may have options such as "hex:group=2"
+		name, format, found := strings.Cut(entry, "=")
+		if !found || strings.Contains(name, ":") {
+			format, name, found = entry, "", false
+		}
+		// Commas separate the entries, so pipeline stages and codec options
+		// are joined with '+'
+		format = ff.ReplaceSeparator(format, headerPipelineSeparator)
+		if err := ff.ValidateFormat(format); err != nil {
+			return "", nil, err
+		}
+
+		if found {
+			overrides[name] = Format(format)
 		} else {
-			// Non-negative value is an absolute offset
-			kafkaOffset = kgo.NewOffset().At(offsetVal)
+			defaultFormat = Format(format)
+		}
+	}
+
+	return defaultFormat, overrides, nil
+}
+
+// splitList splits each value at commas, trimming whitespace and dropping
+// empty entries
+func splitList(values []string) []string {
+	var list []string
+	for _, value := range values {
+		for _, entry := range strings.Split(value, ",") {
+			if entry = strings.TrimSpace(entry); entry != "" {
+				list = append(list, entry)
+			}
+		}
+	}
+	return list
+}
+
+// parsePartitions parses a comma-separated list of partition numbers
+func parsePartitions(spec string) ([]int32, error) {
+	var partitions []int32
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		partition, err := strconv.ParseInt(entry, 10, 32)
+		if err != nil || partition < 0 {
+			return nil, fmt.Errorf("invalid partition '%s'", entry)
 		}
+		if !slices.Contains(partitions, int32(partition)) {
+			partitions = append(partitions, int32(partition))
+		}
+	}
+	return partitions, nil
+}
+
+// parseHeaders parses key=value header arguments into record headers
+func parseHeaders(values []string) ([]kgo.RecordHeader, error) {
+	var headers []kgo.RecordHeader
+	for _, value := range values {
+		key, headerValue, found := strings.Cut(value, "=")
+		if !found || key == "" {
+			return nil, fmt.Errorf("expected key=value, got '%s'", value)
+		}
+		headers = append(headers, kgo.RecordHeader{Key: key, Value: []byte(headerValue)})
+	}
+	return headers, nil
+}
+
+// SchemaOptions returns the schema settings for schema-based codecs. Values
+// are produced with the latest schema of the topic's "<topic>-value" subject.
+func (c *Config) SchemaOptions() ff.SchemaOptions {
+	opts := ff.SchemaOptions{
+		ImportDirs:     c.ProtoImportDirs,
+		DescriptorSets: c.DescriptorSets,
+		MessageType:    c.MessageType,
+		RegistryURL:    c.RegistryURL,
+		AvroSchemaFile: c.AvroSchemaFile,
+		JSONSchemaFile: c.JSONSchemaFile,
+	}
+	if len(c.Topics) == 1 && !c.TopicRegex {
+		opts.Subject = c.Topics[0] + "-value"
 	}
+	return opts
+}
 
-	return kafkaOffset, nil
+// CreateBalancer creates the group balancer selected by the Balancer config value
+func (c *Config) CreateBalancer() (kgo.GroupBalancer, error) {
+	return createBalancer(c.Balancer)
+}
+
+// createBalancer returns the group balancer with the given name
+func createBalancer(name string) (kgo.GroupBalancer, error) {
+	switch name {
+	case "", "cooperative-sticky":
+		return kgo.CooperativeStickyBalancer(), nil
+	case "sticky":
+		return kgo.StickyBalancer(), nil
+	case "range":
+		return kgo.RangeBalancer(), nil
+	case "roundrobin":
+		return kgo.RoundRobinBalancer(), nil
+	default:
+		return nil, fmt.Errorf("unknown balancer '%s'", name)
+	}
 }
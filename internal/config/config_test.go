@@ -3,6 +3,7 @@ package config
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/twmb/franz-go/pkg/kgo"
 )
@@ -168,3 +169,288 @@ func TestCreateConsumerOffsetDefaults(t *testing.T) {
 		}
 	})
 }
+
+// TestCreatePartitionOffsets tests partition selection and per-partition offsets
+func TestCreatePartitionOffsets(t *testing.T) {
+	tests := []struct {
+		name           string
+		consumerOffset string
+		partitions     []int32
+		expected       map[int32]kgo.Offset
+		expectError    bool
+		errorContains  string
+	}{
+		{
+			name:           "whole topic",
+			consumerOffset: "beginning",
+			expected:       nil,
+		},
+		{
+			name:           "selected partitions share offset",
+			consumerOffset: "beginning",
+			partitions:     []int32{0, 2},
+			expected: map[int32]kgo.Offset{
+				0: kgo.NewOffset().AtStart(),
+				2: kgo.NewOffset().AtStart(),
+			},
+		},
+		{
+			name:           "per-partition offsets without -p",
+			consumerOffset: "0:100,3:beginning",
+			expected: map[int32]kgo.Offset{
+				0: kgo.NewOffset().At(100),
+				3: kgo.NewOffset().AtStart(),
+			},
+		},
+		{
+			name:           "per-partition offsets with default",
+			consumerOffset: "1:-5,end",
+			partitions:     []int32{0, 1},
+			expected: map[int32]kgo.Offset{
+				0: kgo.NewOffset().AtEnd(),
+				1: kgo.NewOffset().Relative(-5),
+			},
+		},
+		{
+			name:           "offset for unselected partition",
+			consumerOffset: "2:100",
+			partitions:     []int32{0, 1},
+			expectError:    true,
+			errorContains:  "not selected",
+		},
+		{
+			name:           "duplicate partition offset",
+			consumerOffset: "0:1,0:2",
+			expectError:    true,
+			errorContains:  "multiple offsets",
+		},
+		{
+			name:           "multiple default offsets",
+			consumerOffset: "beginning,end",
+			partitions:     []int32{0},
+			expectError:    true,
+			errorContains:  "multiple default offsets",
+		},
+		{
+			name:           "invalid partition offset",
+			consumerOffset: "0:invalid",
+			expectError:    true,
+			errorContains:  "invalid offset value",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				ConsumerOffset: tt.consumerOffset,
+				Partitions:     tt.partitions,
+			}
+
+			offsets, err := cfg.CreatePartitionOffsets()
+			if tt.expectError {
+				if err == nil {
+					t.Fatalf("Expected error, got nil")
+				}
+				if !strings.Contains(err.Error(), tt.errorContains) {
+					t.Errorf("Expected error containing '%s', got '%s'", tt.errorContains, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			if tt.expected == nil {
+				if offsets != nil {
+					t.Errorf("Expected nil offsets, got %v", offsets)
+				}
+				return
+			}
+			if len(offsets) != len(tt.expected) {
+				t.Fatalf("Expected %d partitions, got %d: %v", len(tt.expected), len(offsets), offsets)
+			}
+			for partition, expected := range tt.expected {
+				if offsets[partition] != expected {
+					t.Errorf("Expected offset %v for partition %d, got %v", expected, partition, offsets[partition])
+				}
+			}
+		})
+	}
+}
+
+// TestCreateConsumerOffsetIgnoresPartitionEntries tests that per-partition
+// entries do not affect the topic-wide offset
+func TestCreateConsumerOffsetIgnoresPartitionEntries(t *testing.T) {
+	cfg := &Config{
+		ConsumerOffset: "0:100,beginning",
+	}
+
+	offset, err := cfg.CreateConsumerOffset()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if offset != kgo.NewOffset().AtStart() {
+		t.Errorf("Expected offset at start, got %v", offset)
+	}
+}
+
+// TestCreateConsumerOffsetTimestamps tests timestamp and duration offsets
+func TestCreateConsumerOffsetTimestamps(t *testing.T) {
+	origNow := now
+	defer func() { now = origNow }()
+	now = func() time.Time { return time.UnixMilli(1697040000000) }
+
+	tests := []struct {
+		name           string
+		consumerOffset string
+		expected       kgo.Offset
+		expectError    bool
+		errorContains  string
+	}{
+		{
+			name:           "unix millis",
+			consumerOffset: "s@1697040000000",
+			expected:       kgo.NewOffset().AfterMilli(1697040000000),
+		},
+		{
+			name:           "RFC3339 timestamp",
+			consumerOffset: "2023-10-11T16:00:00Z",
+			expected:       kgo.NewOffset().AfterMilli(1697040000000),
+		},
+		{
+			name:           "RFC3339 timestamp with zone offset",
+			consumerOffset: "2023-10-11T19:00:00+03:00",
+			expected:       kgo.NewOffset().AfterMilli(1697040000000),
+		},
+		{
+			name:           "negative duration",
+			consumerOffset: "-1h",
+			expected:       kgo.NewOffset().AfterMilli(1697040000000 - 3600000),
+		},
+		{
+			name:           "compound duration",
+			consumerOffset: "-1h30m",
+			expected:       kgo.NewOffset().AfterMilli(1697040000000 - 5400000),
+		},
+		{
+			name:           "zero is still an absolute offset",
+			consumerOffset: "0",
+			expected:       kgo.NewOffset().At(0),
+		},
+		{
+			name:           "positive duration",
+			consumerOffset: "1h",
+			expectError:    true,
+			errorContains:  "duration must be negative",
+		},
+		{
+			name:           "invalid unix millis",
+			consumerOffset: "s@yesterday",
+			expectError:    true,
+			errorContains:  "unix milliseconds",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				ConsumerOffset: tt.consumerOffset,
+			}
+
+			offset, err := cfg.CreateConsumerOffset()
+			if tt.expectError {
+				if err == nil {
+					t.Fatalf("Expected error, got nil")
+				}
+				if !strings.Contains(err.Error(), tt.errorContains) {
+					t.Errorf("Expected error containing '%s', got '%s'", tt.errorContains, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if offset != tt.expected {
+				t.Errorf("Expected offset %v, got %v", tt.expected, offset)
+			}
+		})
+	}
+}
+
+// TestCreatePartitionOffsetsTimestamps tests timestamps in per-partition offsets
+func TestCreatePartitionOffsetsTimestamps(t *testing.T) {
+	cfg := &Config{
+		ConsumerOffset: "0:2023-10-11T16:00:00Z,1:s@1697040000000",
+	}
+
+	offsets, err := cfg.CreatePartitionOffsets()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := kgo.NewOffset().AfterMilli(1697040000000)
+	for _, partition := range []int32{0, 1} {
+		if offsets[partition] != expected {
+			t.Errorf("Expected offset %v for partition %d, got %v", expected, partition, offsets[partition])
+		}
+	}
+}
+
+// TestOffsetResolve tests resolving offsets against a partition's log range
+func TestOffsetResolve(t *testing.T) {
+	tests := []struct {
+		name            string
+		offset          Offset
+		timestampOffset int64
+		expected        int64
+	}{
+		{name: "beginning", offset: Offset{Kind: OffsetBeginning}, expected: 100},
+		{name: "end", offset: Offset{Kind: OffsetEnd}, expected: 200},
+		{name: "absolute", offset: Offset{Kind: OffsetAbsolute, Value: 150}, expected: 150},
+		{name: "absolute before start", offset: Offset{Kind: OffsetAbsolute, Value: 5}, expected: 100},
+		{name: "absolute after end", offset: Offset{Kind: OffsetAbsolute, Value: 500}, expected: 200},
+		{name: "relative", offset: Offset{Kind: OffsetRelative, Value: -10}, expected: 190},
+		{name: "relative before start", offset: Offset{Kind: OffsetRelative, Value: -1000}, expected: 100},
+		{name: "timestamp", offset: Offset{Kind: OffsetTimestamp, Value: 1697040000000}, timestampOffset: 170, expected: 170},
+		{name: "timestamp without offset", offset: Offset{Kind: OffsetTimestamp, Value: 1697040000000}, timestampOffset: -1, expected: 200},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.offset.Resolve(100, 200, tt.timestampOffset); got != tt.expected {
+				t.Errorf("Expected %d, got %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestStopOffset tests parsing the end bound and bounded mode detection
+func TestStopOffset(t *testing.T) {
+	cfg := &Config{ExitAtEnd: true}
+	if !cfg.Bounded() {
+		t.Errorf("Expected Bounded()=true with ExitAtEnd")
+	}
+	offset, err := cfg.StopOffset()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if offset.Kind != OffsetEnd {
+		t.Errorf("Expected end offset, got %v", offset)
+	}
+
+	cfg = &Config{EndOffset: "s@1697040000000"}
+	if !cfg.Bounded() {
+		t.Errorf("Expected Bounded()=true with EndOffset")
+	}
+	offset, err = cfg.StopOffset()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if offset != (Offset{Kind: OffsetTimestamp, Value: 1697040000000}) {
+		t.Errorf("Expected timestamp offset, got %v", offset)
+	}
+
+	if (&Config{}).Bounded() {
+		t.Errorf("Expected Bounded()=false by default")
+	}
+}
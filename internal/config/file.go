@@ -0,0 +1,126 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Environment variables selecting the config file and profile
+const (
+	ConfigFileEnv = "KAFKADOG_CONFIG"
+	ProfileEnv    = "KAFKADOG_PROFILE"
+)
+
+// configFile is the layout of the config file
+type configFile struct {
+	Default  string                    `yaml:"default"`  // Profile used when none is selected
+	Profiles map[string]map[string]any `yaml:"profiles"` // Option values by profile name
+}
+
+// defaultConfigPath returns the path of the config file under
+// $XDG_CONFIG_HOME, or ~/.config when it is not set
+func defaultConfigPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "kafkadog", "config.yaml")
+}
+
+// applyProfile sets the flags of the given flag set that were not already
+// set, on the command line or from the environment, from a config file
+// profile. The file defaults to
+// defaultConfigPath, and may be missing unless it or a profile was selected
+// explicitly. Without a selected profile, the file's default profile is used.
+func applyProfile(flags *flag.FlagSet, path, profile string) error {
+	explicitPath := path != ""
+	if !explicitPath {
+		path = defaultConfigPath()
+	}
+
+	file, err := loadConfigFile(path)
+	if errors.Is(err, fs.ErrNotExist) && !explicitPath && profile == "" {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if profile == "" {
+		profile = file.Default
+		if profile == "" {
+			return nil
+		}
+	}
+
+	values, ok := file.Profiles[profile]
+	if !ok {
+		return fmt.Errorf("profile '%s' not found in config file %s", profile, path)
+	}
+
+	explicit := setFlags(flags)
+
+	for name, value := range values {
+		flagName, ok := options[name]
+		if !ok {
+			return fmt.Errorf("unknown option '%s' in profile '%s'", name, profile)
+		}
+		if explicit[flagName] {
+			continue
+		}
+		if err := setFlag(flags, flagName, value); err != nil {
+			return fmt.Errorf("invalid value for option '%s' in profile '%s': %w", name, profile, err)
+		}
+	}
+
+	return nil
+}
+
+// loadConfigFile reads and parses the config file at path
+func loadConfigFile(path string) (*configFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var file configFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return &file, nil
+}
+
+// setFlag sets a flag from a config file value. Each element of a list sets
+// a repeatable flag once; for other flags the elements are joined by commas.
+func setFlag(flags *flag.FlagSet, name string, value any) error {
+	list, ok := value.([]any)
+	if !ok {
+		return flags.Set(name, fmt.Sprint(value))
+	}
+
+	if _, repeatable := flags.Lookup(name).Value.(*stringList); repeatable {
+		for _, element := range list {
+			if err := flags.Set(name, fmt.Sprint(element)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	elements := make([]string, len(list))
+	for i, element := range list {
+		elements[i] = fmt.Sprint(element)
+	}
+	return flags.Set(name, strings.Join(elements, ","))
+}
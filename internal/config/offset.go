@@ -0,0 +1,277 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// OffsetKind identifies how an Offset position is specified
+type OffsetKind int
+
+const (
+	OffsetEnd       OffsetKind = iota // The end of the partition
+	OffsetBeginning                   // The start of the partition
+	OffsetAbsolute                    // The absolute offset in Value
+	OffsetRelative                    // Value (negative) records before the end of the partition
+	OffsetTimestamp                   // The first offset at or after Value unix milliseconds
+)
+
+// Offset is a parsed consumer offset position
+type Offset struct {
+	Kind  OffsetKind
+	Value int64
+}
+
+// KgoOffset converts the offset to a kgo.Offset
+func (o Offset) KgoOffset() kgo.Offset {
+	switch o.Kind {
+	case OffsetBeginning:
+		return kgo.NewOffset().AtStart()
+	case OffsetAbsolute:
+		return kgo.NewOffset().At(o.Value)
+	case OffsetRelative:
+		return kgo.NewOffset().Relative(o.Value)
+	case OffsetTimestamp:
+		return kgo.NewOffset().AfterMilli(o.Value)
+	default:
+		return kgo.NewOffset().AtEnd()
+	}
+}
+
+// Resolve returns the absolute offset within a partition whose log spans from
+// start up to end. For timestamp offsets, timestampOffset is the first offset
+// at or after the timestamp as listed by the broker.
+func (o Offset) Resolve(start, end, timestampOffset int64) int64 {
+	var offset int64
+	switch o.Kind {
+	case OffsetBeginning:
+		offset = start
+	case OffsetAbsolute:
+		offset = o.Value
+	case OffsetRelative:
+		offset = end + o.Value
+	case OffsetTimestamp:
+		offset = timestampOffset
+		if offset < 0 {
+			offset = end
+		}
+	default:
+		offset = end
+	}
+
+	return max(start, min(offset, end))
+}
+
+// CreateConsumerOffset creates a kgo.Offset based on the ConsumerOffset config value
+// It handles "beginning", "end", numerical offset values (both absolute and relative)
+// and timestamps. Per-partition entries (partition:offset) are ignored, see CreatePartitionOffsets.
+func (c *Config) CreateConsumerOffset() (kgo.Offset, error) {
+	defaultOffset, _, err := splitOffsetSpec(c.ConsumerOffset)
+	if err != nil {
+		return kgo.NewOffset().AtEnd(), err
+	}
+
+	offset, err := parseOffset(defaultOffset)
+	return offset.KgoOffset(), err
+}
+
+// CreatePartitionOffsets creates per-partition starting offsets when partitions
+// are selected with -p or offsets are given per partition in -o. It returns nil
+// when the whole topic should be consumed from the offset returned by
+// CreateConsumerOffset.
+func (c *Config) CreatePartitionOffsets() (map[int32]kgo.Offset, error) {
+	_, perPartition, err := splitOffsetSpec(c.ConsumerOffset)
+	if err != nil {
+		return nil, err
+	}
+
+	// Leave an invalid topic-wide offset to CreateConsumerOffset
+	if len(c.Partitions) == 0 && len(perPartition) == 0 {
+		return nil, nil
+	}
+
+	_, partitionOffsets, err := c.ConsumerOffsets()
+	if err != nil {
+		return nil, err
+	}
+
+	offsets := make(map[int32]kgo.Offset, len(partitionOffsets))
+	for partition, offset := range partitionOffsets {
+		offsets[partition] = offset.KgoOffset()
+	}
+	return offsets, nil
+}
+
+// ConsumerOffsets parses the ConsumerOffset config value into the offset for
+// the whole topic and, when partitions are selected with -p or offsets are
+// given per partition, the starting offset of each selected partition
+func (c *Config) ConsumerOffsets() (Offset, map[int32]Offset, error) {
+	defaultValue, perPartition, err := splitOffsetSpec(c.ConsumerOffset)
+	if err != nil {
+		return Offset{}, nil, err
+	}
+
+	defaultOffset, err := parseOffset(defaultValue)
+	if err != nil {
+		return Offset{}, nil, err
+	}
+
+	if len(c.Partitions) == 0 && len(perPartition) == 0 {
+		return defaultOffset, nil, nil
+	}
+
+	partitions := c.Partitions
+	if len(partitions) == 0 {
+		// Without -p, consume exactly the partitions that have an offset
+		for partition := range perPartition {
+			partitions = append(partitions, partition)
+		}
+	} else {
+		for partition := range perPartition {
+			if !slices.Contains(partitions, partition) {
+				return Offset{}, nil, fmt.Errorf("offset given for partition %d which is not selected with -p", partition)
+			}
+		}
+	}
+
+	offsets := make(map[int32]Offset, len(partitions))
+	for _, partition := range partitions {
+		value, ok := perPartition[partition]
+		if !ok {
+			offsets[partition] = defaultOffset
+			continue
+		}
+
+		offsets[partition], err = parseOffset(value)
+		if err != nil {
+			return Offset{}, nil, fmt.Errorf("partition %d: %w", partition, err)
+		}
+	}
+
+	return defaultOffset, offsets, nil
+}
+
+// StopOffset parses the EndOffset config value. It returns the end of each
+// partition when only ExitAtEnd is set.
+func (c *Config) StopOffset() (Offset, error) {
+	return parseOffset(c.EndOffset)
+}
+
+// Bounded reports whether consumption stops at an end offset
+func (c *Config) Bounded() bool {
+	return c.ExitAtEnd || c.EndOffset != ""
+}
+
+// splitOffsetSpec splits a consumer offset specification into comma-separated
+// entries: at most one offset applying to all partitions, and any number of
+// partition:offset entries
+func splitOffsetSpec(spec string) (string, map[int32]string, error) {
+	var defaultOffset string
+	perPartition := make(map[int32]string)
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if partitionStr, offset, found := strings.Cut(entry, ":"); found {
+			if partition, err := strconv.ParseInt(partitionStr, 10, 32); err == nil {
+				if partition < 0 {
+					return "", nil, fmt.Errorf("invalid partition %d in offset '%s'", partition, entry)
+				}
+				if _, exists := perPartition[int32(partition)]; exists {
+					return "", nil, fmt.Errorf("multiple offsets given for partition %d", partition)
+				}
+				perPartition[int32(partition)] = offset
+				continue
+			}
+		}
+
+		if defaultOffset != "" {
+			return "", nil, fmt.Errorf("multiple default offsets given: '%s' and '%s'", defaultOffset, entry)
+		}
+		defaultOffset = entry
+	}
+
+	return defaultOffset, perPartition, nil
+}
+
+// now returns the current time, replaceable in tests
+var now = time.Now
+
+// parseOffset parses a single offset value: "beginning", "end", an absolute
+// offset, a negative offset relative to the end, a timestamp given as
+// s@<unix millis> or RFC3339, or a negative duration relative to the current
+// time such as -1h. Empty means end.
+func parseOffset(value string) (Offset, error) {
+	// Default to consuming from the end of the topic
+	offset := Offset{Kind: OffsetEnd}
+
+	// Handle empty string as end
+	if value == "" {
+		return offset, nil
+	}
+
+	switch value {
+	case "beginning":
+		offset = Offset{Kind: OffsetBeginning}
+	case "end":
+		offset = Offset{Kind: OffsetEnd}
+	default:
+		// Try to parse as integer
+		offsetVal, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			// Timestamps resolve to the first offset at or after the given time
+			millis, ok, err := parseTimestamp(value)
+			if !ok {
+				return offset, fmt.Errorf("invalid offset value '%s': expected 'beginning', 'end', an offset, s@<unix millis>, an RFC3339 timestamp or a negative duration", value)
+			}
+			if err != nil {
+				return offset, fmt.Errorf("invalid offset value '%s': %w", value, err)
+			}
+			return Offset{Kind: OffsetTimestamp, Value: millis}, nil
+		}
+
+		if offsetVal < 0 {
+			// Negative value means relative offset from end
+			offset = Offset{Kind: OffsetRelative, Value: offsetVal}
+		} else {
+			// Non-negative value is an absolute offset
+			offset = Offset{Kind: OffsetAbsolute, Value: offsetVal}
+		}
+	}
+
+	return offset, nil
+}
+
+// parseTimestamp parses s@<unix millis>, RFC3339 timestamps and negative
+// durations relative to now into unix milliseconds. The boolean result
+// reports whether the value looks like a timestamp at all.
+func parseTimestamp(value string) (int64, bool, error) {
+	if millisStr, found := strings.CutPrefix(value, "s@"); found {
+		millis, err := strconv.ParseInt(millisStr, 10, 64)
+		if err != nil || millis < 0 {
+			return 0, true, fmt.Errorf("expected unix milliseconds after 's@'")
+		}
+		return millis, true, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UnixMilli(), true, nil
+	}
+
+	if d, err := time.ParseDuration(value); err == nil {
+		if d >= 0 {
+			return 0, true, fmt.Errorf("duration must be negative, e.g. -%s", value)
+		}
+		return now().Add(d).UnixMilli(), true, nil
+	}
+
+	return 0, false, nil
+}
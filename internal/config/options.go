@@ -0,0 +1,106 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// envPrefix prefixes the environment variable of each option
+const envPrefix = "KAFKADOG_"
+
+// options maps the option names used in config file profiles and environment
+// variables to the flags they set
+var options = map[string]string{
+	"brokers":                       "b",
+	"topic":                         "t",
+	"regex":                         "regex",
+	"format":                        "f",
+	"key-format":                    "fk",
+	"value-format":                  "fv",
+	"header-format":                 "fh",
+	"produce":                       "P",
+	"consume":                       "C",
+	"count":                         "c",
+	"proto-import-dirs":             "I",
+	"descriptor-set":                "descriptor-set",
+	"message-type":                  "M",
+	"schema-registry":               "r",
+	"schema-registry-password-file": "r-password-file",
+	"avro-schema":                   "avsc",
+	"json-schema":                   "json-schema",
+	"compression":                   "z",
+	"output-format":                 "F",
+	"json":                          "J",
+	"header":                        "H",
+	"key-delimiter":                 "K",
+	"offset":                        "o",
+	"exit-at-end":                   "e",
+	"end-offset":                    "u",
+	"partitions":                    "p",
+	"group":                         "G",
+	"balancer":                      "balancer",
+	"commit":                        "commit",
+	"tls":                           "tls",
+	"tls-ca":                        "tls-ca",
+	"tls-cert":                      "tls-cert",
+	"tls-key":                       "tls-key",
+	"tls-server-name":               "tls-server-name",
+	"tls-insecure":                  "tls-insecure",
+	"sasl":                          "sasl",
+	"sasl-user":                     "sasl-user",
+	"sasl-password-file":            "sasl-password-file",
+	"sasl-token-cmd":                "sasl-token-cmd",
+}
+
+// optionGroups lists flags that choose between alternatives, so setting one
+// of them overrides the others too
+var optionGroups = [][]string{
+	{"P", "C"},
+}
+
+// setFlags returns the names of the flags that were set in the given flag set,
+// along with the other flags of their option groups
+func setFlags(flags *flag.FlagSet) map[string]bool {
+	set := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	for _, group := range optionGroups {
+		if slices.ContainsFunc(group, func(name string) bool { return set[name] }) {
+			for _, name := range group {
+				set[name] = true
+			}
+		}
+	}
+
+	return set
+}
+
+// envName returns the environment variable for an option, e.g.
+// KAFKADOG_KEY_FORMAT for key-format
+func envName(option string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(option, "-", "_"))
+}
+
+// applyEnv sets the flags of the given flag set that were not given on the
+// command line from their KAFKADOG_* environment variables. Empty variables
+// are ignored.
+func applyEnv(flags *flag.FlagSet) error {
+	explicit := setFlags(flags)
+
+	for option, flagName := range options {
+		value := os.Getenv(envName(option))
+		if value == "" || explicit[flagName] {
+			continue
+		}
+		if err := flags.Set(flagName, value); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", envName(option), err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// SchemaRegistryPasswordEnv is the environment variable holding the Schema
+// Registry password
+const SchemaRegistryPasswordEnv = "KAFKADOG_SCHEMA_REGISTRY_PASSWORD"
+
+// registryURLWithPassword sets the password of the user in a Schema Registry
+// URL from the password file, falling back to the
+// KAFKADOG_SCHEMA_REGISTRY_PASSWORD environment variable. The URL is returned
+// as it is when neither is set.
+func registryURLWithPassword(rawURL, passwordFile string) (string, error) {
+	password, err := readPassword(passwordFile, SchemaRegistryPasswordEnv)
+	if err != nil {
+		return "", fmt.Errorf("failed to read Schema Registry password file: %w", err)
+	}
+	if password == "" {
+		return rawURL, nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid Schema Registry URL (-r): %w", err)
+	}
+	if u.User == nil || u.User.Username() == "" {
+		return "", fmt.Errorf("Schema Registry password given without a user in the URL (-r), e.g. 'https://API_KEY@host'")
+	}
+	u.User = url.UserPassword(u.User.Username(), password)
+	return u.String(), nil
+}
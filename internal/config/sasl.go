@@ -0,0 +1,118 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/twmb/franz-go/pkg/sasl"
+	"github.com/twmb/franz-go/pkg/sasl/oauth"
+	"github.com/twmb/franz-go/pkg/sasl/plain"
+	"github.com/twmb/franz-go/pkg/sasl/scram"
+)
+
+// SASL mechanisms supported for broker authentication
+const (
+	SASLPlain       = "PLAIN"
+	SASLScramSHA256 = "SCRAM-SHA-256"
+	SASLScramSHA512 = "SCRAM-SHA-512"
+	SASLOAuthBearer = "OAUTHBEARER"
+)
+
+// SASLPasswordEnv is the environment variable holding the SASL password
+const SASLPasswordEnv = "KAFKADOG_SASL_PASSWORD"
+
+// SASLOptions holds the settings for authenticating to brokers
+type SASLOptions struct {
+	Mechanism    string // SASL mechanism, empty for no authentication (-sasl flag)
+	Username     string // Username for PLAIN and SCRAM (-sasl-user flag)
+	Password     string // Password for PLAIN and SCRAM, from a file (-sasl-password-file flag) or KAFKADOG_SASL_PASSWORD
+	TokenCommand string // Shell command printing an OAUTHBEARER token (-sasl-token-cmd flag)
+}
+
+// SASLMechanism creates the SASL mechanism for broker authentication. It
+// returns nil when no mechanism is configured.
+func (c *Config) SASLMechanism() (sasl.Mechanism, error) {
+	opts := c.SASL
+	switch opts.Mechanism {
+	case "":
+		return nil, nil
+	case SASLPlain:
+		return plain.Auth{User: opts.Username, Pass: opts.Password}.AsMechanism(), nil
+	case SASLScramSHA256:
+		return scram.Auth{User: opts.Username, Pass: opts.Password}.AsSha256Mechanism(), nil
+	case SASLScramSHA512:
+		return scram.Auth{User: opts.Username, Pass: opts.Password}.AsSha512Mechanism(), nil
+	case SASLOAuthBearer:
+		// The command runs for every connection so refreshed tokens are picked up
+		return oauth.Oauth(func(ctx context.Context) (oauth.Auth, error) {
+			token, err := runTokenCommand(ctx, opts.TokenCommand)
+			if err != nil {
+				return oauth.Auth{}, err
+			}
+			return oauth.Auth{Token: token}, nil
+		}), nil
+	default:
+		return nil, fmt.Errorf("unknown SASL mechanism '%s'", opts.Mechanism)
+	}
+}
+
+// validateSASL checks that the credentials required by the mechanism are set
+func validateSASL(opts SASLOptions) error {
+	switch opts.Mechanism {
+	case "":
+		if opts.Username != "" || opts.TokenCommand != "" {
+			return fmt.Errorf("SASL credentials given without a mechanism (-sasl)")
+		}
+	case SASLPlain, SASLScramSHA256, SASLScramSHA512:
+		if opts.Username == "" {
+			return fmt.Errorf("SASL mechanism %s requires a username (-sasl-user)", opts.Mechanism)
+		}
+		if opts.Password == "" {
+			return fmt.Errorf("SASL mechanism %s requires a password from -sasl-password-file or %s", opts.Mechanism, SASLPasswordEnv)
+		}
+	case SASLOAuthBearer:
+		if opts.TokenCommand == "" {
+			return fmt.Errorf("SASL mechanism %s requires a token command (-sasl-token-cmd)", opts.Mechanism)
+		}
+	default:
+		return fmt.Errorf("unknown SASL mechanism '%s'. Must be one of: %s, %s, %s, %s", opts.Mechanism, SASLPlain, SASLScramSHA256, SASLScramSHA512, SASLOAuthBearer)
+	}
+	return nil
+}
+
+// readPassword reads a password from the password file, falling back to the
+// given environment variable. A single trailing newline in the file is
+// ignored.
+func readPassword(passwordFile, env string) (string, error) {
+	if passwordFile == "" {
+		return os.Getenv(env), nil
+	}
+
+	password, err := os.ReadFile(passwordFile)
+	if err != nil {
+		return "", err
+	}
+	password = bytes.TrimSuffix(password, []byte("\n"))
+	password = bytes.TrimSuffix(password, []byte("\r"))
+	return string(password), nil
+}
+
+// runTokenCommand runs a shell command and returns its output as a token
+func runTokenCommand(ctx context.Context, command string) (string, error) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stderr = os.Stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("SASL token command failed: %w", err)
+	}
+
+	token := strings.TrimSpace(string(output))
+	if token == "" {
+		return "", fmt.Errorf("SASL token command printed no token")
+	}
+	return token, nil
+}
@@ -0,0 +1,152 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSASLMechanism(t *testing.T) {
+	tests := []struct {
+		name         string
+		opts         SASLOptions
+		expectedName string
+		expectedMsg  string // Expected first client message, empty to skip
+		expectError  bool
+	}{
+		{
+			name:         "no mechanism",
+			opts:         SASLOptions{},
+			expectedName: "",
+		},
+		{
+			name:         "plain",
+			opts:         SASLOptions{Mechanism: SASLPlain, Username: "alice", Password: "secret"},
+			expectedName: "PLAIN",
+			expectedMsg:  "\x00alice\x00secret",
+		},
+		{
+			name:         "scram sha 256",
+			opts:         SASLOptions{Mechanism: SASLScramSHA256, Username: "alice", Password: "secret"},
+			expectedName: "SCRAM-SHA-256",
+		},
+		{
+			name:         "scram sha 512",
+			opts:         SASLOptions{Mechanism: SASLScramSHA512, Username: "alice", Password: "secret"},
+			expectedName: "SCRAM-SHA-512",
+		},
+		{
+			name:         "oauthbearer",
+			opts:         SASLOptions{Mechanism: SASLOAuthBearer, TokenCommand: "echo my-token"},
+			expectedName: "OAUTHBEARER",
+			expectedMsg:  "n,,\x01auth=Bearer my-token\x01\x01",
+		},
+		{
+			name:        "unknown mechanism",
+			opts:        SASLOptions{Mechanism: "GSSAPI"},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{SASL: tt.opts}
+			mechanism, err := cfg.SASLMechanism()
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if tt.expectedName == "" {
+				if mechanism != nil {
+					t.Errorf("Expected no mechanism, got %s", mechanism.Name())
+				}
+				return
+			}
+			if mechanism.Name() != tt.expectedName {
+				t.Errorf("Expected mechanism %s, got %s", tt.expectedName, mechanism.Name())
+			}
+
+			if tt.expectedMsg != "" {
+				_, msg, err := mechanism.Authenticate(context.Background(), "localhost:9092")
+				if err != nil {
+					t.Fatalf("Unexpected authentication error: %v", err)
+				}
+				if string(msg) != tt.expectedMsg {
+					t.Errorf("Expected first message %q, got %q", tt.expectedMsg, msg)
+				}
+			}
+		})
+	}
+}
+
+func TestSASLTokenCommandErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+	}{
+		{"command fails", "exit 1"},
+		{"empty token", "echo"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{SASL: SASLOptions{Mechanism: SASLOAuthBearer, TokenCommand: tt.command}}
+			mechanism, err := cfg.SASLMechanism()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if _, _, err := mechanism.Authenticate(context.Background(), "localhost:9092"); err == nil {
+				t.Errorf("Expected authentication error, got nil")
+			}
+		})
+	}
+}
+
+func TestReadPassword(t *testing.T) {
+	dir := t.TempDir()
+	passwordFile := filepath.Join(dir, "password")
+	if err := os.WriteFile(passwordFile, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write password file: %v", err)
+	}
+	t.Setenv(SASLPasswordEnv, "from-env")
+
+	tests := []struct {
+		name        string
+		file        string
+		expected    string
+		expectError bool
+	}{
+		{"environment variable", "", "from-env", false},
+		{"file takes precedence", passwordFile, "from-file", false},
+		{"missing file", filepath.Join(dir, "missing"), "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			password, err := readPassword(tt.file, SASLPasswordEnv)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if password != tt.expected {
+				t.Errorf("Expected password '%s', got '%s'", tt.expected, password)
+			}
+			if strings.ContainsAny(password, "\r\n") {
+				t.Errorf("Expected password without line endings, got %q", password)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// TLSOptions holds the settings for encrypted broker connections
+type TLSOptions struct {
+	Enabled    bool   // Connect to brokers over TLS (-tls flag, implied by the other TLS flags)
+	CAFile     string // PEM bundle of CAs to verify brokers with instead of the system pool (-tls-ca flag)
+	CertFile   string // PEM client certificate for mutual TLS (-tls-cert flag)
+	KeyFile    string // PEM private key of the client certificate (-tls-key flag)
+	ServerName string // Server name to verify broker certificates against (-tls-server-name flag)
+	Insecure   bool   // Skip broker certificate verification (-tls-insecure flag)
+}
+
+// TLSConfig creates the TLS configuration for broker connections. It returns
+// nil when TLS is not enabled.
+func (c *Config) TLSConfig() (*tls.Config, error) {
+	opts := c.TLS
+	if !opts.Enabled {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         opts.ServerName,
+		InsecureSkipVerify: opts.Insecure,
+	}
+
+	if opts.CAFile != "" {
+		pem, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", opts.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if opts.CertFile != "" || opts.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
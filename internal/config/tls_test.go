@@ -0,0 +1,267 @@
+package config
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testPKI is a throwaway CA with a server and a client certificate
+type testPKI struct {
+	caFile     string
+	serverCert tls.Certificate
+	clientCert string
+	clientKey  string
+	caPool     *x509.CertPool
+}
+
+// newTestPKI creates a CA and certificates signed by it, writing the CA and
+// client certificate to PEM files in a temporary directory
+func newTestPKI(t *testing.T) *testPKI {
+	t.Helper()
+	dir := t.TempDir()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate CA key: %v", err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "kafkadog test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("Failed to create CA certificate: %v", err)
+	}
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatalf("Failed to parse CA certificate: %v", err)
+	}
+
+	issue := func(serial int64, usage x509.ExtKeyUsage, dnsNames []string) ([]byte, []byte) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatalf("Failed to generate key: %v", err)
+		}
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: "kafkadog test"},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+			DNSNames:     dnsNames,
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+		if err != nil {
+			t.Fatalf("Failed to create certificate: %v", err)
+		}
+		keyDER, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			t.Fatalf("Failed to marshal key: %v", err)
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+			pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	}
+
+	serverCertPEM, serverKeyPEM := issue(2, x509.ExtKeyUsageServerAuth, []string{"kafka.internal"})
+	serverCert, err := tls.X509KeyPair(serverCertPEM, serverKeyPEM)
+	if err != nil {
+		t.Fatalf("Failed to load server certificate: %v", err)
+	}
+	clientCertPEM, clientKeyPEM := issue(3, x509.ExtKeyUsageClientAuth, nil)
+
+	pki := &testPKI{
+		caFile:     filepath.Join(dir, "ca.pem"),
+		serverCert: serverCert,
+		clientCert: filepath.Join(dir, "client.pem"),
+		clientKey:  filepath.Join(dir, "client-key.pem"),
+		caPool:     x509.NewCertPool(),
+	}
+	pki.caPool.AddCert(caCert)
+
+	for file, data := range map[string][]byte{
+		pki.caFile:     pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}),
+		pki.clientCert: clientCertPEM,
+		pki.clientKey:  clientKeyPEM,
+	} {
+		if err := os.WriteFile(file, data, 0o600); err != nil {
+			t.Fatalf("Failed to write %s: %v", file, err)
+		}
+	}
+
+	return pki
+}
+
+// listen starts a local TLS listener which completes the handshake with each
+// connection and writes a single byte to it
+func (p *testPKI) listen(t *testing.T, requireClientCert bool) string {
+	t.Helper()
+
+	serverConfig := &tls.Config{Certificates: []tls.Certificate{p.serverCert}}
+	if requireClientCert {
+		serverConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		serverConfig.ClientCAs = p.caPool
+	}
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", serverConfig)
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				if err := conn.(*tls.Conn).Handshake(); err == nil {
+					conn.Write([]byte{1})
+				}
+			}()
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+// handshake connects to addr with the TLS configuration and reads the byte
+// written by the listener, which fails if either side rejected the handshake
+func handshake(addr string, tlsConfig *tls.Config) error {
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 5 * time.Second}, "tcp", addr, tlsConfig)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	_, err = conn.Read(make([]byte, 1))
+	return err
+}
+
+func TestTLSConfigDisabled(t *testing.T) {
+	cfg := &Config{}
+	tlsConfig, err := cfg.TLSConfig()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if tlsConfig != nil {
+		t.Errorf("Expected no TLS config when TLS is disabled, got %v", tlsConfig)
+	}
+}
+
+func TestTLSConfigHandshake(t *testing.T) {
+	pki := newTestPKI(t)
+	addr := pki.listen(t, false)
+	mutualAddr := pki.listen(t, true)
+
+	tests := []struct {
+		name        string
+		opts        TLSOptions
+		addr        string
+		expectError bool
+	}{
+		{
+			name: "CA and server name",
+			opts: TLSOptions{Enabled: true, CAFile: pki.caFile, ServerName: "kafka.internal"},
+			addr: addr,
+		},
+		{
+			name:        "CA without server name override",
+			opts:        TLSOptions{Enabled: true, CAFile: pki.caFile},
+			addr:        addr,
+			expectError: true,
+		},
+		{
+			name:        "wrong server name",
+			opts:        TLSOptions{Enabled: true, CAFile: pki.caFile, ServerName: "other.internal"},
+			addr:        addr,
+			expectError: true,
+		},
+		{
+			name:        "system CAs",
+			opts:        TLSOptions{Enabled: true, ServerName: "kafka.internal"},
+			addr:        addr,
+			expectError: true,
+		},
+		{
+			name: "insecure",
+			opts: TLSOptions{Enabled: true, Insecure: true},
+			addr: addr,
+		},
+		{
+			name: "mutual TLS",
+			opts: TLSOptions{Enabled: true, CAFile: pki.caFile, ServerName: "kafka.internal", CertFile: pki.clientCert, KeyFile: pki.clientKey},
+			addr: mutualAddr,
+		},
+		{
+			name:        "mutual TLS without client certificate",
+			opts:        TLSOptions{Enabled: true, CAFile: pki.caFile, ServerName: "kafka.internal"},
+			addr:        mutualAddr,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{TLS: tt.opts}
+			tlsConfig, err := cfg.TLSConfig()
+			if err != nil {
+				t.Fatalf("Unexpected error creating TLS config: %v", err)
+			}
+
+			err = handshake(tt.addr, tlsConfig)
+			if tt.expectError && err == nil {
+				t.Errorf("Expected handshake to fail, but it succeeded")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Expected handshake to succeed, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestTLSConfigErrors(t *testing.T) {
+	pki := newTestPKI(t)
+	notPEM := filepath.Join(t.TempDir(), "not-pem.txt")
+	if err := os.WriteFile(notPEM, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		opts TLSOptions
+	}{
+		{"missing CA file", TLSOptions{Enabled: true, CAFile: filepath.Join(t.TempDir(), "missing.pem")}},
+		{"CA file without certificates", TLSOptions{Enabled: true, CAFile: notPEM}},
+		{"missing client key", TLSOptions{Enabled: true, CertFile: pki.clientCert, KeyFile: filepath.Join(t.TempDir(), "missing.pem")}},
+		{"mismatched client key", TLSOptions{Enabled: true, CertFile: pki.clientCert, KeyFile: pki.caFile}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{TLS: tt.opts}
+			if _, err := cfg.TLSConfig(); err == nil {
+				t.Errorf("Expected error, got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jarkkom/kafkadog/internal/config"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// Bounds limits consumption to a fixed offset range in each partition
+type Bounds struct {
+	Start map[string]map[int32]int64 // First offset to consume
+	End   map[string]map[int32]int64 // Offset to stop before
+}
+
+// ResolveBounds resolves the configured start offsets and end bound into
+// absolute offset ranges, using the partition offsets listed when consumption
+// starts. Partitions with nothing to consume are left out.
+func ResolveBounds(ctx context.Context, adm *kadm.Client, cfg *config.Config) (*Bounds, error) {
+	defaultOffset, partitionOffsets, err := cfg.ConsumerOffsets()
+	if err != nil {
+		return nil, err
+	}
+
+	stopOffset, err := cfg.StopOffset()
+	if err != nil {
+		return nil, err
+	}
+
+	topics := cfg.Topics
+	starts, err := listOffsets(adm.ListStartOffsets(ctx, topics...))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list start offsets: %w", err)
+	}
+	ends, err := listOffsets(adm.ListEndOffsets(ctx, topics...))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list end offsets: %w", err)
+	}
+
+	for _, topic := range topics {
+		for partition := range partitionOffsets {
+			if _, ok := ends.Lookup(topic, partition); !ok {
+				return nil, fmt.Errorf("partition %d does not exist in topic %s", partition, topic)
+			}
+		}
+	}
+
+	// Timestamps are looked up once each, shared by all partitions
+	timestamps := make(map[int64]kadm.ListedOffsets)
+	timestampOffset := func(offset config.Offset, topic string, partition int32) (int64, error) {
+		if offset.Kind != config.OffsetTimestamp {
+			return -1, nil
+		}
+		listed, ok := timestamps[offset.Value]
+		if !ok {
+			var err error
+			listed, err = listOffsets(adm.ListOffsetsAfterMilli(ctx, offset.Value, topics...))
+			if err != nil {
+				return -1, fmt.Errorf("failed to list offsets for timestamp %d: %w", offset.Value, err)
+			}
+			timestamps[offset.Value] = listed
+		}
+		if o, ok := listed.Lookup(topic, partition); ok {
+			return o.Offset, nil
+		}
+		return -1, nil
+	}
+
+	bounds := &Bounds{
+		Start: make(map[string]map[int32]int64, len(topics)),
+		End:   make(map[string]map[int32]int64, len(topics)),
+	}
+
+	for _, topic := range topics {
+		bounds.Start[topic] = make(map[int32]int64)
+		bounds.End[topic] = make(map[int32]int64)
+
+		for partition, end := range ends[topic] {
+			startOffset := defaultOffset
+			if partitionOffsets != nil {
+				var ok bool
+				if startOffset, ok = partitionOffsets[partition]; !ok {
+					continue
+				}
+			}
+
+			low, high := end.Offset, end.Offset
+			if start, ok := starts.Lookup(topic, partition); ok {
+				low = start.Offset
+			}
+
+			startTimestamp, err := timestampOffset(startOffset, topic, partition)
+			if err != nil {
+				return nil, err
+			}
+			stopTimestamp, err := timestampOffset(stopOffset, topic, partition)
+			if err != nil {
+				return nil, err
+			}
+
+			from := startOffset.Resolve(low, high, startTimestamp)
+			until := high
+			if cfg.EndOffset != "" {
+				until = stopOffset.Resolve(low, high, stopTimestamp)
+				// An absolute end offset may lie beyond the current end
+				if stopOffset.Kind == config.OffsetAbsolute {
+					until = stopOffset.Value
+				}
+			}
+			if cfg.ExitAtEnd {
+				until = min(until, high)
+			}
+
+			if from < until {
+				bounds.Start[topic][partition] = from
+				bounds.End[topic][partition] = until
+			}
+		}
+	}
+
+	return bounds, nil
+}
+
+// remaining returns a copy of the end offsets to track partitions which have
+// not been consumed up to their end yet
+func (b *Bounds) remaining() map[string]map[int32]int64 {
+	remaining := make(map[string]map[int32]int64, len(b.End))
+	for topic, partitions := range b.End {
+		if len(partitions) == 0 {
+			continue
+		}
+		remaining[topic] = make(map[int32]int64, len(partitions))
+		for partition, offset := range partitions {
+			remaining[topic][partition] = offset
+		}
+	}
+	return remaining
+}
+
+// StartOffsets returns the start of each bounded partition as kgo offsets
+func (b *Bounds) StartOffsets() map[string]map[int32]kgo.Offset {
+	offsets := make(map[string]map[int32]kgo.Offset, len(b.Start))
+	for topic, partitions := range b.Start {
+		offsets[topic] = make(map[int32]kgo.Offset, len(partitions))
+		for partition, offset := range partitions {
+			offsets[topic][partition] = kgo.NewOffset().At(offset)
+		}
+	}
+	return offsets
+}
+
+// listOffsets returns listed offsets, surfacing per-partition errors
+func listOffsets(listed kadm.ListedOffsets, err error) (kadm.ListedOffsets, error) {
+	if err != nil {
+		return nil, err
+	}
+	if err := listed.Error(); err != nil {
+		return nil, err
+	}
+	return listed, nil
+}
@@ -3,6 +3,7 @@ package consumer
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"sync"
 
@@ -11,53 +12,108 @@ import (
 	"github.com/twmb/franz-go/pkg/kgo"
 )
 
+// recordCommitter commits the offsets of processed records for a consumer group
+type recordCommitter interface {
+	MarkCommitRecords(records ...*kgo.Record)
+	CommitRecords(ctx context.Context, records ...*kgo.Record) error
+}
+
 // Consumer handles Kafka message consumption
 type Consumer struct {
 	client       *kgo.Client
-	codec        format.Codec
-	messageCount int // Number of messages to read, 0 for unlimited
+	committer    recordCommitter
+	codecs       *recordCodecs
+	formatter    recordFormatter
+	out          io.Writer
+	messageCount int                        // Number of messages to read, 0 for unlimited
+	messagesRead int                        // Number of messages printed
+	commitMode   string                     // Group offset commit mode, empty when not in a group
+	remaining    map[string]map[int32]int64 // End offsets of partitions not fully consumed, nil if unbounded
 }
 
-// New creates a new Consumer instance
-func New(client *kgo.Client, formatStr config.Format, messageCount int, protoImportDirs []string, messageType string) (*Consumer, error) {
-	var codec format.Codec
-	var err error
-
-	// Handle protobuf format specially
-	if string(formatStr) == "protobuf" {
-		// Use schema-based protobuf codec if import dirs and message type are provided
-		if len(protoImportDirs) > 0 && messageType != "" {
-			codec, err = format.NewCodecWithSchema("protobuf", protoImportDirs, messageType)
-		} else {
-			codec, err = format.NewCodec("protobuf")
-		}
+// New creates a new Consumer instance. When bounds is non-nil, the consumer
+// stops once every bounded partition has been consumed up to its end offset.
+func New(client *kgo.Client, cfg *config.Config, bounds *Bounds) (*Consumer, error) {
+	keyCodec, err := format.NewCodec(string(cfg.KeyFormat))
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize key codec: %w", err)
+	}
+
+	// Schema-based protobuf decoding applies to values only
+	valueFormat := format.WithCompression(string(cfg.ValueFormat), cfg.Compression)
+	valueCodec, err := format.NewCodecWithSchema(valueFormat, cfg.SchemaOptions())
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize value codec: %w", err)
+	}
+
+	codecs := &recordCodecs{
+		key:     keyCodec,
+		value:   valueCodec,
+		headers: make(map[string]format.Codec, len(cfg.HeaderFormats)),
+	}
+	codecs.header, err = format.NewCodec(string(cfg.HeaderFormat))
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize header codec: %w", err)
+	}
+	for name, headerFormat := range cfg.HeaderFormats {
+		codecs.headers[name], err = format.NewCodec(string(headerFormat))
 		if err != nil {
-			return nil, fmt.Errorf("failed to initialize protobuf codec: %w", err)
+			return nil, fmt.Errorf("failed to initialize codec for header %s: %w", name, err)
 		}
+	}
+
+	var formatter recordFormatter
+	if cfg.JSONMode {
+		formatter = newJSONFormatter(codecs)
 	} else {
-		codec, err = format.NewCodec(string(formatStr))
+		outputFormat := cfg.OutputFormat
+		if outputFormat == "" {
+			outputFormat = defaultOutputFormat
+			if len(cfg.Topics) > 1 || cfg.TopicRegex {
+				outputFormat = defaultMultiTopicOutputFormat
+			}
+		}
+		formatter, err = newTemplateFormatter(outputFormat, codecs)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("invalid output format (-F): %w", err)
 		}
 	}
 
-	return &Consumer{
+	c := &Consumer{
 		client:       client,
-		codec:        codec,
-		messageCount: messageCount,
-	}, nil
+		committer:    client,
+		codecs:       codecs,
+		formatter:    formatter,
+		out:          os.Stdout,
+		messageCount: cfg.MessageCount,
+	}
+	if cfg.Group != "" {
+		c.commitMode = cfg.CommitMode
+	}
+	if bounds != nil {
+		c.remaining = bounds.remaining()
+	}
+
+	return c, nil
 }
 
 // Run starts the consumer writing to stdout
 func (c *Consumer) Run(ctx context.Context, wg *sync.WaitGroup) {
 	defer wg.Done()
-
-	// Counter for messages read
-	messagesRead := 0
+	defer func() {
+		if err := c.codecs.close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error closing codecs: %v\n", err)
+		}
+	}()
 
 	for {
 		// Check if we've reached the message limit
-		if c.messageCount > 0 && messagesRead >= c.messageCount {
+		if c.messageCount > 0 && c.messagesRead >= c.messageCount {
+			return
+		}
+
+		// Check if every bounded partition has been consumed
+		if c.remaining != nil && len(c.remaining) == 0 {
 			return
 		}
 
@@ -70,31 +126,76 @@ func (c *Consumer) Run(ctx context.Context, wg *sync.WaitGroup) {
 				return
 			}
 
-			if errs := fetches.Errors(); len(errs) > 0 {
-				for _, err := range errs {
-					fmt.Fprintf(os.Stderr, "Error consuming from topic %s: %v\n", err.Topic, err.Err)
-				}
-				continue
+			// Errors are per partition, records fetched from the others are still printed
+			for _, err := range fetches.Errors() {
+				fmt.Fprintf(os.Stderr, "Error consuming from topic %s: %v\n", err.Topic, err.Err)
 			}
 
-			fetches.EachRecord(func(record *kgo.Record) {
-				// Check if we've reached the message limit
-				if c.messageCount > 0 && messagesRead >= c.messageCount {
-					return
-				}
+			fetches.EachPartition(func(p kgo.FetchTopicPartition) {
+				c.processPartition(ctx, p)
+			})
+		}
+	}
+}
 
-				// Apply the configured format (which handles protobuf if needed)
-				encoded, err := c.codec.Encode(record.Value)
-				if err != nil {
-					fmt.Fprintf(os.Stderr, "Error formatting output: %v\n", err)
-					return
-				}
+// processPartition prints the records of a fetched partition, committing each
+// printed record for the consumer group
+func (c *Consumer) processPartition(ctx context.Context, p kgo.FetchTopicPartition) {
+	for _, record := range c.boundedRecords(p) {
+		// Check if we've reached the message limit
+		if c.messageCount > 0 && c.messagesRead >= c.messageCount {
+			return
+		}
 
-				fmt.Println(string(encoded))
+		// Render the record through the output format and codec
+		if err := c.formatter.Format(c.out, record); err != nil {
+			fmt.Fprintf(os.Stderr, "Error formatting output: %v\n", err)
+			continue
+		}
 
-				// Increment the message counter
-				messagesRead++
-			})
+		c.commit(ctx, record)
+
+		// Increment the message counter
+		c.messagesRead++
+	}
+}
+
+// boundedRecords returns the records of a fetched partition to output,
+// leaving out control records and records past the end offset of the
+// partition. The partition is done once the fetch position after its last
+// record reaches the end offset, so that transaction markers and offsets left
+// empty by compaction still count towards the end.
+func (c *Consumer) boundedRecords(p kgo.FetchTopicPartition) []*kgo.Record {
+	records := make([]*kgo.Record, 0, len(p.Records))
+	partitions := c.remaining[p.Topic]
+	end, bounded := partitions[p.Partition]
+	for _, record := range p.Records {
+		if record.Attrs.IsControl() {
+			continue
+		}
+		if c.remaining != nil && (!bounded || record.Offset >= end) {
+			continue
+		}
+		records = append(records, record)
+	}
+
+	if bounded && len(p.Records) > 0 && p.Records[len(p.Records)-1].Offset+1 >= end {
+		delete(partitions, p.Partition)
+		if len(partitions) == 0 {
+			delete(c.remaining, p.Topic)
+		}
+	}
+	return records
+}
+
+// commit records the record as processed for the consumer group
+func (c *Consumer) commit(ctx context.Context, record *kgo.Record) {
+	switch c.commitMode {
+	case config.CommitAuto:
+		c.committer.MarkCommitRecords(record)
+	case config.CommitManual:
+		if err := c.committer.CommitRecords(ctx, record); err != nil {
+			fmt.Fprintf(os.Stderr, "Error committing offset %d for topic %s partition %d: %v\n", record.Offset, record.Topic, record.Partition, err)
 		}
 	}
 }
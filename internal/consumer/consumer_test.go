@@ -0,0 +1,250 @@
+package consumer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/jarkkom/kafkadog/internal/config"
+	"github.com/jarkkom/kafkadog/internal/format"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// testBatch is a record batch with records at the given offsets
+type testBatch struct {
+	offsets []int64
+	control bool // Transaction marker batch
+}
+
+// fetchPartition builds a fetched partition from record batches as kgo
+// returns them with control records kept
+func fetchPartition(t *testing.T, partition int32, batches ...testBatch) kgo.FetchTopicPartition {
+	t.Helper()
+
+	var data []byte
+	for _, b := range batches {
+		var records []byte
+		for _, offset := range b.offsets {
+			record := kmsg.Record{OffsetDelta: int32(offset - b.offsets[0]), Value: []byte("v")}
+			if b.control {
+				record.Key = []byte{0, 0, 0, 1} // Commit marker
+			}
+			record.Length = int32(len(record.AppendTo(nil)) - 1)
+			records = record.AppendTo(records)
+		}
+
+		batch := kmsg.RecordBatch{
+			FirstOffset:     b.offsets[0],
+			Magic:           2,
+			LastOffsetDelta: int32(b.offsets[len(b.offsets)-1] - b.offsets[0]),
+			NumRecords:      int32(len(b.offsets)),
+			Records:         records,
+		}
+		if b.control {
+			batch.Attributes = 0x30 // Transactional control batch
+		}
+		batch.Length = int32(len(batch.AppendTo(nil)) - 12)
+		data = batch.AppendTo(data)
+	}
+
+	fp, _ := kgo.ProcessFetchPartition(kgo.ProcessFetchPartitionOpts{
+		KeepControlRecords:   true,
+		DisableCRCValidation: true,
+		Offset:               batches[0].offsets[0],
+		Topic:                "events",
+		Partition:            partition,
+	}, &kmsg.FetchResponseTopicPartition{Partition: partition, RecordBatches: data}, nil, nil)
+	if fp.Err != nil {
+		t.Fatalf("Failed to process fetch: %v", fp.Err)
+	}
+	return kgo.FetchTopicPartition{Topic: "events", FetchPartition: fp}
+}
+
+// TestBoundedRecords tests tracking bounded partitions until their end offset
+func TestBoundedRecords(t *testing.T) {
+	bounds := &Bounds{
+		Start: map[string]map[int32]int64{"events": {0: 10, 1: 0, 2: 5, 3: 0}},
+		End:   map[string]map[int32]int64{"events": {0: 12, 1: 1, 2: 8, 3: 4}},
+	}
+	c := &Consumer{remaining: bounds.remaining()}
+
+	steps := []struct {
+		name      string
+		partition int32
+		batches   []testBatch
+		expected  []int64
+		remaining int
+	}{
+		{
+			name:      "records before the end",
+			partition: 0,
+			batches:   []testBatch{{offsets: []int64{10}}},
+			expected:  []int64{10},
+			remaining: 4,
+		},
+		{
+			name:      "last record",
+			partition: 1,
+			batches:   []testBatch{{offsets: []int64{0}}},
+			expected:  []int64{0},
+			remaining: 3,
+		},
+		{
+			name:      "partition already done",
+			partition: 1,
+			batches:   []testBatch{{offsets: []int64{1}}},
+			expected:  []int64{},
+			remaining: 3,
+		},
+		{
+			name:      "partition outside the bounds",
+			partition: 4,
+			batches:   []testBatch{{offsets: []int64{0}}},
+			expected:  []int64{},
+			remaining: 3,
+		},
+		{
+			name:      "transaction marker at the last offset",
+			partition: 2,
+			batches:   []testBatch{{offsets: []int64{5, 6}}, {offsets: []int64{7}, control: true}},
+			expected:  []int64{5, 6},
+			remaining: 2,
+		},
+		{
+			name:      "compacted last offset",
+			partition: 3,
+			batches:   []testBatch{{offsets: []int64{1, 2}}, {offsets: []int64{5}}},
+			expected:  []int64{1, 2},
+			remaining: 1,
+		},
+		{
+			name:      "records past the end",
+			partition: 0,
+			batches:   []testBatch{{offsets: []int64{11, 12}}},
+			expected:  []int64{11},
+			remaining: 0,
+		},
+	}
+
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			records := c.boundedRecords(fetchPartition(t, step.partition, step.batches...))
+			offsets := make([]int64, 0, len(records))
+			for _, record := range records {
+				offsets = append(offsets, record.Offset)
+			}
+			if !slices.Equal(offsets, step.expected) {
+				t.Errorf("Expected offsets %v, got %v", step.expected, offsets)
+			}
+			if got := len(c.remaining["events"]); got != step.remaining {
+				t.Errorf("Expected %d remaining partitions, got %d", step.remaining, got)
+			}
+		})
+	}
+
+	if len(c.remaining) != 0 {
+		t.Errorf("Expected all partitions done, got %v", c.remaining)
+	}
+	if len(bounds.End["events"]) != 4 {
+		t.Errorf("Expected bounds to be left unchanged, got %v", bounds.End)
+	}
+}
+
+// TestBoundedRecordsUnbounded tests that every data record is returned without bounds
+func TestBoundedRecordsUnbounded(t *testing.T) {
+	c := &Consumer{}
+	p := fetchPartition(t, 0, testBatch{offsets: []int64{1 << 40}}, testBatch{offsets: []int64{1<<40 + 1}, control: true})
+	if records := c.boundedRecords(p); len(records) != 1 || records[0].Offset != 1<<40 {
+		t.Errorf("Expected only the data record, got %v", records)
+	}
+}
+
+// testCommitter records the offsets marked and committed for a group
+type testCommitter struct {
+	marked    []int64
+	committed []int64
+}
+
+// MarkCommitRecords records the offsets of records marked for committing
+func (c *testCommitter) MarkCommitRecords(records ...*kgo.Record) {
+	for _, record := range records {
+		c.marked = append(c.marked, record.Offset)
+	}
+}
+
+// CommitRecords records the offsets of committed records
+func (c *testCommitter) CommitRecords(_ context.Context, records ...*kgo.Record) error {
+	for _, record := range records {
+		c.committed = append(c.committed, record.Offset)
+	}
+	return nil
+}
+
+// failingCodec fails to encode the value "bad"
+type failingCodec struct {
+	format.RawCodec
+}
+
+// Encode fails for the value "bad"
+func (c *failingCodec) Encode(input []byte) ([]byte, error) {
+	if string(input) == "bad" {
+		return nil, fmt.Errorf("cannot encode 'bad'")
+	}
+	return input, nil
+}
+
+// TestCommitModes tests that only printed records are committed for a group
+func TestCommitModes(t *testing.T) {
+	p := kgo.FetchTopicPartition{Topic: "events", FetchPartition: kgo.FetchPartition{
+		Records: []*kgo.Record{
+			{Topic: "events", Offset: 0, Value: []byte("a")},
+			{Topic: "events", Offset: 1, Value: []byte("bad")},
+			{Topic: "events", Offset: 2, Value: []byte("b")},
+			{Topic: "events", Offset: 3, Value: []byte("c")},
+		},
+	}}
+
+	tests := []struct {
+		name              string
+		commitMode        string
+		expectedMarked    []int64
+		expectedCommitted []int64
+	}{
+		{name: "auto", commitMode: config.CommitAuto, expectedMarked: []int64{0, 2}},
+		{name: "manual", commitMode: config.CommitManual, expectedCommitted: []int64{0, 2}},
+		{name: "not in a group"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			formatter, err := newTemplateFormatter("%s\\n", testCodecs(&failingCodec{}))
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			committer := &testCommitter{}
+			var out bytes.Buffer
+			c := &Consumer{
+				committer:    committer,
+				formatter:    formatter,
+				out:          &out,
+				messageCount: 2, // Stops before offset 3
+				commitMode:   tt.commitMode,
+			}
+
+			c.processPartition(context.Background(), p)
+
+			if out.String() != "a\nb\n" {
+				t.Errorf("Expected output %q, got %q", "a\nb\n", out.String())
+			}
+			if !slices.Equal(committer.marked, tt.expectedMarked) {
+				t.Errorf("Expected marked offsets %v, got %v", tt.expectedMarked, committer.marked)
+			}
+			if !slices.Equal(committer.committed, tt.expectedCommitted) {
+				t.Errorf("Expected committed offsets %v, got %v", tt.expectedCommitted, committer.committed)
+			}
+		})
+	}
+}
@@ -0,0 +1,313 @@
+package consumer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/jarkkom/kafkadog/internal/format"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// defaultOutputFormat prints only the record value followed by a newline
+const defaultOutputFormat = "%s\\n"
+
+// defaultMultiTopicOutputFormat prefixes each value with its topic when
+// consuming more than one topic
+const defaultMultiTopicOutputFormat = "%t\\t%s\\n"
+
+// recordFormatter renders a consumed record for output
+type recordFormatter interface {
+	Format(w io.Writer, record *kgo.Record) error
+}
+
+// recordCodecs holds the codecs used to render the parts of a record
+type recordCodecs struct {
+	key     format.Codec
+	value   format.Codec
+	header  format.Codec            // Default codec for header values
+	headers map[string]format.Codec // Per-header-name codec overrides
+
+	keyWarning sync.Once // Warns once about keys the key codec cannot render
+}
+
+// headerCodec returns the codec for the header with the given name
+func (c *recordCodecs) headerCodec(name string) format.Codec {
+	if codec, ok := c.headers[name]; ok {
+		return codec
+	}
+	return c.header
+}
+
+// warnKeyFallback warns once that keys are shown as they are because the key
+// codec cannot render them
+func (c *recordCodecs) warnKeyFallback(err error) {
+	c.keyWarning.Do(func() {
+		fmt.Fprintf(os.Stderr, "Warning: failed to format key, showing keys as they are: %v\n", err)
+	})
+}
+
+// close releases the resources held by the codecs
+func (c *recordCodecs) close() error {
+	errs := []error{format.CloseCodec(c.key), format.CloseCodec(c.value), format.CloseCodec(c.header)}
+	for _, codec := range c.headers {
+		errs = append(errs, format.CloseCodec(codec))
+	}
+	return errors.Join(errs...)
+}
+
+// formatToken is either a literal string or a single formatting verb
+type formatToken struct {
+	literal string
+	verb    byte
+}
+
+// templateFormatter renders records using a kcat-style format string
+type templateFormatter struct {
+	tokens []formatToken
+	codecs *recordCodecs
+}
+
+// newTemplateFormatter parses a kcat-style format string. Supported tokens:
+//
+//	%t topic, %p partition, %o offset, %T timestamp (milliseconds),
+//	%k key, %K key length, %s value, %S value length,
+//	%h headers (comma separated key=value, values rendered through their
+//	header codec), %% literal percent sign
+//
+// The escapes \n, \r, \t and \\ are also recognized. Keys the key codec cannot
+// render are written as they are.
+func newTemplateFormatter(template string, codecs *recordCodecs) (*templateFormatter, error) {
+	var tokens []formatToken
+	var literal []byte
+
+	flush := func() {
+		if len(literal) > 0 {
+			tokens = append(tokens, formatToken{literal: string(literal)})
+			literal = nil
+		}
+	}
+
+	for i := 0; i < len(template); i++ {
+		ch := template[i]
+		switch ch {
+		case '%':
+			if i+1 >= len(template) {
+				return nil, fmt.Errorf("format string ends with a dangling '%%'")
+			}
+			i++
+			verb := template[i]
+			switch verb {
+			case '%':
+				literal = append(literal, '%')
+			case 't', 'p', 'o', 'T', 'k', 'K', 's', 'S', 'h':
+				flush()
+				tokens = append(tokens, formatToken{verb: verb})
+			default:
+				return nil, fmt.Errorf("unknown format token '%%%c'", verb)
+			}
+		case '\\':
+			if i+1 >= len(template) {
+				literal = append(literal, '\\')
+				continue
+			}
+			i++
+			switch template[i] {
+			case 'n':
+				literal = append(literal, '\n')
+			case 'r':
+				literal = append(literal, '\r')
+			case 't':
+				literal = append(literal, '\t')
+			case '\\':
+				literal = append(literal, '\\')
+			default:
+				literal = append(literal, '\\', template[i])
+			}
+		default:
+			literal = append(literal, ch)
+		}
+	}
+	flush()
+
+	return &templateFormatter{
+		tokens: tokens,
+		codecs: codecs,
+	}, nil
+}
+
+// Format writes the rendered record to w
+func (f *templateFormatter) Format(w io.Writer, record *kgo.Record) error {
+	var buf bytes.Buffer
+
+	for _, tok := range f.tokens {
+		if tok.verb == 0 {
+			buf.WriteString(tok.literal)
+			continue
+		}
+
+		switch tok.verb {
+		case 't':
+			buf.WriteString(record.Topic)
+		case 'p':
+			buf.WriteString(strconv.FormatInt(int64(record.Partition), 10))
+		case 'o':
+			buf.WriteString(strconv.FormatInt(record.Offset, 10))
+		case 'T':
+			buf.WriteString(strconv.FormatInt(record.Timestamp.UnixMilli(), 10))
+		case 'k':
+			if record.Key == nil {
+				continue
+			}
+			encoded, err := f.codecs.key.Encode(record.Key)
+			if err != nil {
+				// Keys are often plain strings even when values need a schema
+				f.codecs.warnKeyFallback(err)
+				encoded = record.Key
+			}
+			buf.Write(encoded)
+		case 'K':
+			buf.WriteString(strconv.Itoa(len(record.Key)))
+		case 's':
+			encoded, err := f.codecs.value.Encode(record.Value)
+			if err != nil {
+				return fmt.Errorf("failed to format value: %w", err)
+			}
+			buf.Write(encoded)
+		case 'S':
+			buf.WriteString(strconv.Itoa(len(record.Value)))
+		case 'h':
+			for i, header := range record.Headers {
+				if i > 0 {
+					buf.WriteByte(',')
+				}
+				encoded, err := f.codecs.headerCodec(header.Key).Encode(header.Value)
+				if err != nil {
+					return fmt.Errorf("failed to format header %s: %w", header.Key, err)
+				}
+				buf.WriteString(header.Key)
+				buf.WriteByte('=')
+				buf.Write(encoded)
+			}
+		}
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// jsonEnvelope is the JSON representation of a consumed record
+type jsonEnvelope struct {
+	Topic         string          `json:"topic"`
+	Partition     int32           `json:"partition"`
+	Offset        int64           `json:"offset"`
+	Timestamp     int64           `json:"timestamp"`
+	TimestampType string          `json:"timestamp_type"`
+	Key           json.RawMessage `json:"key"`
+	Headers       []jsonHeader    `json:"headers"`
+	Value         json.RawMessage `json:"value"`
+}
+
+// jsonHeader is the JSON representation of a record header
+type jsonHeader struct {
+	Key   string          `json:"key"`
+	Value json.RawMessage `json:"value"`
+}
+
+// jsonFormatter renders each record as a single-line JSON envelope
+type jsonFormatter struct {
+	codecs *recordCodecs
+}
+
+// newJSONFormatter creates a formatter emitting one JSON object per record
+func newJSONFormatter(codecs *recordCodecs) *jsonFormatter {
+	return &jsonFormatter{
+		codecs: codecs,
+	}
+}
+
+// Format writes the record as a JSON object followed by a newline to w. Keys
+// the key codec cannot render become strings.
+func (f *jsonFormatter) Format(w io.Writer, record *kgo.Record) error {
+	key, err := encodeJSONField(f.codecs.key, record.Key)
+	if err != nil {
+		// Keys are often plain strings even when values need a schema
+		f.codecs.warnKeyFallback(err)
+		if key, err = json.Marshal(string(record.Key)); err != nil {
+			return fmt.Errorf("failed to format key: %w", err)
+		}
+	}
+
+	value, err := encodeJSONField(f.codecs.value, record.Value)
+	if err != nil {
+		return fmt.Errorf("failed to format value: %w", err)
+	}
+
+	headers := make([]jsonHeader, 0, len(record.Headers))
+	for _, header := range record.Headers {
+		headerValue, err := encodeJSONField(f.codecs.headerCodec(header.Key), header.Value)
+		if err != nil {
+			return fmt.Errorf("failed to format header %s: %w", header.Key, err)
+		}
+		headers = append(headers, jsonHeader{Key: header.Key, Value: headerValue})
+	}
+
+	envelope := jsonEnvelope{
+		Topic:         record.Topic,
+		Partition:     record.Partition,
+		Offset:        record.Offset,
+		Timestamp:     record.Timestamp.UnixMilli(),
+		TimestampType: timestampTypeName(record.Attrs.TimestampType()),
+		Key:           key,
+		Headers:       headers,
+		Value:         value,
+	}
+
+	output, err := json.Marshal(envelope)
+	if err != nil {
+		return fmt.Errorf("failed to marshal record: %w", err)
+	}
+
+	_, err = w.Write(append(output, '\n'))
+	return err
+}
+
+// encodeJSONField renders data through the codec as a JSON value. Codecs that
+// produce JSON are embedded as nested documents, anything else becomes a string.
+func encodeJSONField(codec format.Codec, data []byte) (json.RawMessage, error) {
+	if data == nil {
+		return json.RawMessage("null"), nil
+	}
+
+	encoded, err := codec.Encode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if format.EncodesJSON(codec) {
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, encoded); err != nil {
+			return nil, fmt.Errorf("codec produced invalid JSON: %w", err)
+		}
+		return buf.Bytes(), nil
+	}
+
+	return json.Marshal(string(encoded))
+}
+
+// timestampTypeName returns a readable name for a record timestamp type
+func timestampTypeName(timestampType int8) string {
+	switch timestampType {
+	case 0:
+		return "create"
+	case 1:
+		return "logappend"
+	default:
+		return "none"
+	}
+}
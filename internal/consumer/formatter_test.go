@@ -0,0 +1,284 @@
+package consumer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jarkkom/kafkadog/internal/format"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// testCodecs returns record codecs using codec for keys and values and raw headers
+func testCodecs(codec format.Codec) *recordCodecs {
+	return &recordCodecs{
+		key:    codec,
+		value:  codec,
+		header: &format.RawCodec{},
+	}
+}
+
+// TestTemplateFormatter tests rendering records through kcat-style format strings
+func TestTemplateFormatter(t *testing.T) {
+	record := &kgo.Record{
+		Topic:     "events",
+		Partition: 3,
+		Offset:    42,
+		Timestamp: time.UnixMilli(1697040000000),
+		Key:       []byte("user-1"),
+		Value:     []byte("hello"),
+		Headers: []kgo.RecordHeader{
+			{Key: "traceparent", Value: []byte("00-abc-01")},
+			{Key: "source", Value: []byte("web")},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		codec    string
+		record   *kgo.Record
+		expected string
+	}{
+		{
+			name:     "default format",
+			template: defaultOutputFormat,
+			codec:    "raw",
+			record:   record,
+			expected: "hello\n",
+		},
+		{
+			name:     "metadata tokens",
+			template: "%t [%p] at %o (%T): %k=%s\\n",
+			codec:    "raw",
+			record:   record,
+			expected: "events [3] at 42 (1697040000000): user-1=hello\n",
+		},
+		{
+			name:     "sizes and headers",
+			template: "%K %S %h",
+			codec:    "raw",
+			record:   record,
+			expected: "6 5 traceparent=00-abc-01,source=web",
+		},
+		{
+			name:     "key and value pass through codec",
+			template: "%k\\t%s",
+			codec:    "hex",
+			record:   record,
+			expected: "757365722d31\t68656c6c6f",
+		},
+		{
+			name:     "null key renders empty",
+			template: "[%k] %K",
+			codec:    "hex",
+			record:   &kgo.Record{Value: []byte("x")},
+			expected: "[] 0",
+		},
+		{
+			name:     "literal percent and backslash",
+			template: "100%% \\\\ done",
+			codec:    "raw",
+			record:   record,
+			expected: "100% \\ done",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codec, err := format.NewCodec(tt.codec)
+			if err != nil {
+				t.Fatalf("Failed to create codec: %v", err)
+			}
+
+			formatter, err := newTemplateFormatter(tt.template, testCodecs(codec))
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			var buf bytes.Buffer
+			if err := formatter.Format(&buf, tt.record); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if buf.String() != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, buf.String())
+			}
+		})
+	}
+}
+
+// TestTemplateFormatterInvalid tests that malformed format strings are rejected
+func TestTemplateFormatterInvalid(t *testing.T) {
+	tests := []struct {
+		name          string
+		template      string
+		errorContains string
+	}{
+		{
+			name:          "unknown token",
+			template:      "%z",
+			errorContains: "unknown format token",
+		},
+		{
+			name:          "dangling percent",
+			template:      "%s %",
+			errorContains: "dangling",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newTemplateFormatter(tt.template, testCodecs(&format.RawCodec{}))
+			if err == nil {
+				t.Fatalf("Expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.errorContains) {
+				t.Errorf("Expected error containing '%s', got '%s'", tt.errorContains, err.Error())
+			}
+		})
+	}
+}
+
+// jsonTestCodec is a codec that passes JSON through and reports JSON output
+type jsonTestCodec struct {
+	format.RawCodec
+}
+
+// EncodesJSON reports that the codec output is JSON
+func (c *jsonTestCodec) EncodesJSON() bool {
+	return true
+}
+
+// TestJSONFormatter tests rendering records as JSON envelopes
+func TestJSONFormatter(t *testing.T) {
+	record := &kgo.Record{
+		Topic:     "events",
+		Partition: 1,
+		Offset:    7,
+		Timestamp: time.UnixMilli(1697040000000),
+		Key:       []byte("user-1"),
+		Value:     []byte(`{"name": "test"}`),
+		Headers:   []kgo.RecordHeader{{Key: "source", Value: []byte("web")}},
+	}
+
+	tests := []struct {
+		name     string
+		codec    format.Codec
+		record   *kgo.Record
+		expected string
+	}{
+		{
+			name:     "string value",
+			codec:    &format.RawCodec{},
+			record:   record,
+			expected: `{"topic":"events","partition":1,"offset":7,"timestamp":1697040000000,"timestamp_type":"create","key":"user-1","headers":[{"key":"source","value":"web"}],"value":"{\"name\": \"test\"}"}` + "\n",
+		},
+		{
+			name:     "nested JSON value",
+			codec:    &jsonTestCodec{},
+			record:   &kgo.Record{Topic: "events", Timestamp: time.UnixMilli(0), Value: record.Value},
+			expected: `{"topic":"events","partition":0,"offset":0,"timestamp":0,"timestamp_type":"create","key":null,"headers":[],"value":{"name":"test"}}` + "\n",
+		},
+		{
+			name:     "hex codec",
+			codec:    &format.HexCodec{},
+			record:   &kgo.Record{Topic: "events", Timestamp: time.UnixMilli(0), Key: []byte{0x01}, Value: []byte{0xff}},
+			expected: `{"topic":"events","partition":0,"offset":0,"timestamp":0,"timestamp_type":"create","key":"01","headers":[],"value":"ff"}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := newJSONFormatter(testCodecs(tt.codec)).Format(&buf, tt.record); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if buf.String() != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, buf.String())
+			}
+		})
+	}
+}
+
+// TestJSONFormatterKeyFallback tests that keys the key codec cannot render
+// are written as strings rather than dropping the record
+func TestJSONFormatterKeyFallback(t *testing.T) {
+	keyCodec, err := format.NewCodec("protobuf")
+	if err != nil {
+		t.Fatalf("Failed to create codec: %v", err)
+	}
+	codecs := &recordCodecs{
+		key:    keyCodec,
+		value:  &jsonTestCodec{},
+		header: &format.RawCodec{},
+	}
+	record := &kgo.Record{
+		Topic:     "events",
+		Timestamp: time.UnixMilli(0),
+		Key:       []byte("user-1"),
+		Value:     []byte(`{"id":"u1"}`),
+	}
+
+	var buf bytes.Buffer
+	if err := newJSONFormatter(codecs).Format(&buf, record); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if expected := `"key":"user-1"`; !strings.Contains(buf.String(), expected) {
+		t.Errorf("Expected output containing %s, got %s", expected, buf.String())
+	}
+
+	buf.Reset()
+	formatter, err := newTemplateFormatter("%k=%s", codecs)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := formatter.Format(&buf, record); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if expected := `user-1={"id":"u1"}`; buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+// TestHeaderCodecs tests rendering header values through per-header codecs
+func TestHeaderCodecs(t *testing.T) {
+	codecs := &recordCodecs{
+		key:    &format.RawCodec{},
+		value:  &format.RawCodec{},
+		header: &format.HexCodec{},
+		headers: map[string]format.Codec{
+			"traceparent": &format.RawCodec{},
+		},
+	}
+	record := &kgo.Record{
+		Topic:     "events",
+		Timestamp: time.UnixMilli(0),
+		Value:     []byte("v"),
+		Headers: []kgo.RecordHeader{
+			{Key: "traceparent", Value: []byte("00-abc-01")},
+			{Key: "id", Value: []byte{0xca, 0xfe}},
+		},
+	}
+
+	formatter, err := newTemplateFormatter("%h", codecs)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := formatter.Format(&buf, record); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if expected := "traceparent=00-abc-01,id=cafe"; buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+
+	buf.Reset()
+	if err := newJSONFormatter(codecs).Format(&buf, record); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if expected := `"headers":[{"key":"traceparent","value":"00-abc-01"},{"key":"id","value":"cafe"}]`; !strings.Contains(buf.String(), expected) {
+		t.Errorf("Expected output containing %s, got %s", expected, buf.String())
+	}
+}
@@ -0,0 +1,165 @@
+package format
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/linkedin/goavro/v2"
+)
+
+// AvroCodec handles binary Avro values, rendered as Avro JSON. The schema is
+// either read from a local .avsc file, or looked up in the Schema Registry for
+// values in its wire format.
+type AvroCodec struct {
+	schema   *goavro.Codec   // Local schema, nil when using the registry
+	registry *SchemaRegistry // Registry for values in the wire format
+	subject  string          // Subject whose latest schema is used to produce values
+
+	codecs map[int]*goavro.Codec // Registered schemas by schema ID
+}
+
+func init() {
+	registerCodecWithOptions("avro", []string{"schema"}, func(opts codecOptions) (Codec, error) {
+		schema := opts.baseSchema()
+		schema.AvroSchemaFile = opts.string("schema", schema.AvroSchemaFile)
+
+		// Keys and headers have no schema flags, and fail only when used
+		if opts.schema == nil && schema.AvroSchemaFile == "" {
+			return &AvroCodec{}, nil
+		}
+		return NewAvroCodec(schema)
+	})
+}
+
+// NewAvroCodec creates an Avro codec from a local schema file or, when a
+// Schema Registry URL is given, using the registry
+func NewAvroCodec(opts SchemaOptions) (*AvroCodec, error) {
+	if opts.RegistryURL != "" {
+		registry, err := NewSchemaRegistry(opts.RegistryURL)
+		if err != nil {
+			return nil, err
+		}
+		return &AvroCodec{
+			registry: registry,
+			subject:  opts.Subject,
+			codecs:   make(map[int]*goavro.Codec),
+		}, nil
+	}
+
+	if opts.AvroSchemaFile == "" {
+		return nil, fmt.Errorf("avro requires a schema file (-avsc) or a Schema Registry (-r)")
+	}
+
+	spec, err := os.ReadFile(opts.AvroSchemaFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Avro schema: %w", err)
+	}
+	schema, err := goavro.NewCodec(string(spec))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Avro schema %s: %w", opts.AvroSchemaFile, err)
+	}
+
+	return &AvroCodec{schema: schema}, nil
+}
+
+// EncodesJSON reports that the codec renders values as JSON
+func (c *AvroCodec) EncodesJSON() bool {
+	return true
+}
+
+// Encode transforms binary Avro to Avro JSON
+func (c *AvroCodec) Encode(input []byte) ([]byte, error) {
+	schema := c.schema
+	if c.registry != nil {
+		id, payload, err := parseWireHeader(input)
+		if err != nil {
+			return nil, err
+		}
+		if schema, err = c.registryCodec(id); err != nil {
+			return nil, err
+		}
+		input = payload
+	}
+	if schema == nil {
+		return nil, fmt.Errorf("avro requires a schema file (-avsc) or a Schema Registry (-r)")
+	}
+
+	native, rest, err := schema.NativeFromBinary(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode Avro data: %w", err)
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("failed to decode Avro data: %d trailing bytes", len(rest))
+	}
+
+	output, err := schema.TextualFromNative(nil, native)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal Avro data to JSON: %w", err)
+	}
+	return output, nil
+}
+
+// Decode transforms Avro JSON to binary Avro. With the Schema Registry, the
+// latest schema of the subject is used and the value is framed in the wire
+// format.
+func (c *AvroCodec) Decode(input []byte) ([]byte, error) {
+	schema := c.schema
+	var output []byte
+	if c.registry != nil {
+		if c.subject == "" {
+			return nil, fmt.Errorf("producing with the Schema Registry requires a subject")
+		}
+		latest, err := c.registry.LatestSchema(c.subject)
+		if err != nil {
+			return nil, err
+		}
+		if schema, err = c.registryCodec(latest.ID); err != nil {
+			return nil, err
+		}
+		output = appendWireHeader(nil, latest.ID)
+	}
+	if schema == nil {
+		return nil, fmt.Errorf("avro requires a schema file (-avsc) or a Schema Registry (-r)")
+	}
+
+	native, rest, err := schema.NativeFromTextual(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Avro JSON: %w", err)
+	}
+	if rest = bytes.TrimSpace(rest); len(rest) > 0 {
+		return nil, fmt.Errorf("failed to parse Avro JSON: unexpected text after the value: %q", rest)
+	}
+
+	output, err = schema.BinaryFromNative(output, native)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode Avro data: %w", err)
+	}
+	return output, nil
+}
+
+// registryCodec returns the codec for the registered schema with the given ID
+func (c *AvroCodec) registryCodec(id int) (*goavro.Codec, error) {
+	if codec, ok := c.codecs[id]; ok {
+		return codec, nil
+	}
+
+	schema, err := c.registry.SchemaByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if schema.schemaType() != SchemaTypeAvro {
+		return nil, fmt.Errorf("schema %d is a %s schema, not %s", id, schema.schemaType(), SchemaTypeAvro)
+	}
+	if len(schema.References) > 0 {
+		return nil, fmt.Errorf("schema %d uses schema references, which are not supported for Avro", id)
+	}
+
+	codec, err := goavro.NewCodec(schema.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Avro schema %d: %w", id, err)
+	}
+
+	c.codecs[id] = codec
+	return codec, nil
+}
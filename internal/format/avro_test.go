@@ -0,0 +1,203 @@
+package format
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testUserAvsc is an Avro schema with nested records, enums and unions
+const testUserAvsc = `{
+  "type": "record",
+  "name": "User",
+  "namespace": "com.example",
+  "fields": [
+    {"name": "id", "type": "string"},
+    {"name": "age", "type": "int"},
+    {"name": "email", "type": ["null", "string"], "default": null},
+    {"name": "status", "type": {"type": "enum", "name": "Status", "symbols": ["ACTIVE", "BLOCKED"]}},
+    {"name": "address", "type": {"type": "record", "name": "Address", "fields": [
+      {"name": "city", "type": "string"}
+    ]}}
+  ]
+}`
+
+const testUserJSON = `{"id":"u1","age":42,"email":{"string":"u1@example.com"},"status":"ACTIVE","address":{"city":"Helsinki"}}`
+
+// writeAvroSchema writes the test schema to a temporary .avsc file
+func writeAvroSchema(t *testing.T, schema string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "user.avsc")
+	if err := os.WriteFile(path, []byte(schema), 0o600); err != nil {
+		t.Fatalf("Failed to write Avro schema: %v", err)
+	}
+	return path
+}
+
+func TestAvroCodecSchemaFile(t *testing.T) {
+	codec, err := NewCodecWithSchema("avro", SchemaOptions{AvroSchemaFile: writeAvroSchema(t, testUserAvsc)})
+	if err != nil {
+		t.Fatalf("Failed to create codec: %v", err)
+	}
+	if !EncodesJSON(codec) {
+		t.Errorf("Expected avro codec to encode JSON")
+	}
+
+	tests := []struct {
+		name        string
+		input       string
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "all fields",
+			input:    testUserJSON,
+			expected: testUserJSON,
+		},
+		{
+			name:     "null union",
+			input:    `{"id":"u2","age":7,"email":null,"status":"BLOCKED","address":{"city":"Oulu"}}`,
+			expected: `{"id":"u2","age":7,"email":null,"status":"BLOCKED","address":{"city":"Oulu"}}`,
+		},
+		{
+			name:        "missing field",
+			input:       `{"id":"u1"}`,
+			expectError: true,
+		},
+		{
+			name:        "unknown enum symbol",
+			input:       `{"id":"u2","age":7,"email":null,"status":"DELETED","address":{"city":"Oulu"}}`,
+			expectError: true,
+		},
+		{
+			name:        "invalid JSON",
+			input:       `{"id":`,
+			expectError: true,
+		},
+		{
+			name:     "trailing whitespace",
+			input:    testUserJSON + " \r\n",
+			expected: testUserJSON,
+		},
+		{
+			name:        "trailing text",
+			input:       testUserJSON + ` garbage`,
+			expectError: true,
+		},
+		{
+			name:        "second value",
+			input:       testUserJSON + testUserJSON,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := codec.Decode([]byte(tt.input))
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to decode JSON: %v", err)
+			}
+
+			output, err := codec.Encode(data)
+			if err != nil {
+				t.Fatalf("Failed to encode data: %v", err)
+			}
+			if !jsonEqual(t, output, []byte(tt.expected)) {
+				t.Errorf("Expected %s, got %s", tt.expected, output)
+			}
+		})
+	}
+}
+
+func TestAvroCodecInvalidBinary(t *testing.T) {
+	codec, err := NewAvroCodec(SchemaOptions{AvroSchemaFile: writeAvroSchema(t, testUserAvsc)})
+	if err != nil {
+		t.Fatalf("Failed to create codec: %v", err)
+	}
+
+	data, err := codec.Decode([]byte(testUserJSON))
+	if err != nil {
+		t.Fatalf("Failed to decode JSON: %v", err)
+	}
+
+	for name, value := range map[string][]byte{
+		"truncated":      data[:len(data)-3],
+		"trailing bytes": append(bytes.Clone(data), 0x01),
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := codec.Encode(value); err == nil {
+				t.Errorf("Expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestAvroCodecRegistry(t *testing.T) {
+	registry := newTestRegistry(t)
+	codec, err := NewCodecWithSchema("avro", SchemaOptions{RegistryURL: registry.url(), Subject: "users-value"})
+	if err != nil {
+		t.Fatalf("Failed to create codec: %v", err)
+	}
+
+	data, err := codec.Decode([]byte(testUserJSON))
+	if err != nil {
+		t.Fatalf("Failed to decode JSON: %v", err)
+	}
+	if header := []byte{0, 0, 0, 0, 9}; !bytes.HasPrefix(data, header) {
+		t.Errorf("Expected wire format header %x, got %x", header, data)
+	}
+
+	for range 2 {
+		output, err := codec.Encode(data)
+		if err != nil {
+			t.Fatalf("Failed to encode data: %v", err)
+		}
+		if !jsonEqual(t, output, []byte(testUserJSON)) {
+			t.Errorf("Expected %s, got %s", testUserJSON, output)
+		}
+	}
+
+	if count := registry.requestCount("/schemas/ids/9"); count > 1 {
+		t.Errorf("Expected schema 9 to be fetched at most once, got %d requests", count)
+	}
+
+	// Protobuf schemas and values without the wire format header are rejected
+	for name, value := range map[string][]byte{
+		"protobuf schema":   {0, 0, 0, 0, 7, 0},
+		"missing framing":   data[5:],
+		"unknown schema id": {0, 0, 0, 0, 8, 0},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := codec.Encode(value); err == nil {
+				t.Errorf("Expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestNewAvroCodecErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		opts SchemaOptions
+	}{
+		{"no schema", SchemaOptions{}},
+		{"missing schema file", SchemaOptions{AvroSchemaFile: filepath.Join(t.TempDir(), "missing.avsc")}},
+		{"invalid schema", SchemaOptions{AvroSchemaFile: writeAvroSchema(t, `{"type":"record"}`)}},
+		{"invalid registry URL", SchemaOptions{RegistryURL: "localhost"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewAvroCodec(tt.opts); err == nil {
+				t.Errorf("Expected error, got nil")
+			}
+		})
+	}
+}
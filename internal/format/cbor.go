@@ -0,0 +1,53 @@
+package format
+
+import (
+	"fmt"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// CBORCodec handles CBOR values, rendered as JSON
+type CBORCodec struct{}
+
+// cborEncMode encodes map keys in a deterministic order
+var cborEncMode, _ = cbor.CoreDetEncOptions().EncMode()
+
+func init() {
+	registerCodec("cbor", func() Codec {
+		return &CBORCodec{}
+	})
+}
+
+// EncodesJSON reports that the codec renders values as JSON
+func (c *CBORCodec) EncodesJSON() bool {
+	return true
+}
+
+// Encode transforms a CBOR value to JSON
+func (c *CBORCodec) Encode(input []byte) ([]byte, error) {
+	var value any
+	if err := cbor.Unmarshal(input, &value); err != nil {
+		return nil, fmt.Errorf("failed to decode CBOR: %w", err)
+	}
+
+	// A tagged value other than a timestamp is rendered by its content
+	if tag, ok := value.(cbor.Tag); ok {
+		value = tag.Content
+	}
+
+	return marshalJSONValue(value)
+}
+
+// Decode transforms JSON to a CBOR value
+func (c *CBORCodec) Decode(input []byte) ([]byte, error) {
+	value, err := unmarshalJSONValue(input)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := cborEncMode.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode CBOR: %w", err)
+	}
+	return output, nil
+}
@@ -0,0 +1,105 @@
+package format
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCBORCodecRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []byte
+	}{
+		{"integer", `42`, []byte{0x18, 0x2a}},
+		{"negative integer", `-1`, []byte{0x20}},
+		{"large unsigned integer", `18446744073709551615`, []byte{0x1b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		{"float", `1.5`, []byte{0xf9, 0x3e, 0x00}},
+		{"string", `"hi"`, []byte{0x62, 'h', 'i'}},
+		{"array", `[true,null]`, []byte{0x82, 0xf5, 0xf6}},
+		{"map with sorted keys", `{"bb":1,"a":2}`, []byte{0xa2, 0x61, 'a', 0x02, 0x62, 'b', 'b', 0x01}},
+	}
+
+	codec, err := NewCodec("cbor")
+	if err != nil {
+		t.Fatalf("Failed to create codec: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := codec.Decode([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("Failed to decode JSON: %v", err)
+			}
+			if !bytes.Equal(data, tt.expected) {
+				t.Errorf("Expected %x, got %x", tt.expected, data)
+			}
+
+			output, err := codec.Encode(data)
+			if err != nil {
+				t.Fatalf("Failed to encode data: %v", err)
+			}
+			if !jsonEqual(t, output, []byte(tt.input)) {
+				t.Errorf("Expected %s, got %s", tt.input, output)
+			}
+		})
+	}
+}
+
+func TestCBORCodecEncode(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       []byte
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "integer map keys",
+			input:    []byte{0xa1, 0x01, 0x61, 'a'},
+			expected: `{"1":"a"}`,
+		},
+		{
+			name:     "byte string",
+			input:    []byte{0x42, 0x01, 0x02},
+			expected: `"AQI="`,
+		},
+		{
+			name:     "tagged value",
+			input:    []byte{0xd8, 0x20, 0x61, 'u'},
+			expected: `"u"`,
+		},
+		{
+			name:        "truncated",
+			input:       []byte{0x82, 0xf5},
+			expectError: true,
+		},
+		{
+			name:        "trailing data",
+			input:       []byte{0x01, 0x01},
+			expectError: true,
+		},
+	}
+
+	codec, err := NewCodec("cbor")
+	if err != nil {
+		t.Fatalf("Failed to create codec: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := codec.Encode(tt.input)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to encode data: %v", err)
+			}
+			if string(output) != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, output)
+			}
+		})
+	}
+}
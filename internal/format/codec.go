@@ -4,6 +4,7 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"fmt"
+	"io"
 	"strings"
 )
 
@@ -15,25 +16,70 @@ type Codec interface {
 	Encode(input []byte) ([]byte, error)
 }
 
-// NewCodec returns a codec for the specified format
-func NewCodec(format string) (Codec, error) {
-	factory, ok := codecRegistry[format]
-	if ok {
-		return factory(), nil
+// JSONEncoder is implemented by codecs whose Encode output is a JSON document,
+// allowing callers to embed the output as JSON rather than as a string
+type JSONEncoder interface {
+	// EncodesJSON reports whether Encode produces JSON
+	EncodesJSON() bool
+}
+
+// EncodesJSON reports whether the codec produces JSON from Encode
+func EncodesJSON(codec Codec) bool {
+	if enc, ok := codec.(JSONEncoder); ok {
+		return enc.EncodesJSON()
 	}
+	return false
+}
 
-	return nil, fmt.Errorf("unsupported format: %s", format)
+// CloseCodec releases the resources held by a codec, such as the command of
+// an exec codec. Codecs without resources are left as they are.
+func CloseCodec(codec Codec) error {
+	if closer, ok := codec.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
 }
 
-// NewCodecWithSchema returns a codec for the specified format with schema support
-func NewCodecWithSchema(format string, importDirs []string, messageType string) (Codec, error) {
-	// For schema-based protobuf, create a special codec
-	if format == "protobuf" && len(importDirs) > 0 && messageType != "" {
-		return NewProtoSchemaCodec(importDirs, messageType)
+// NewCodec returns a codec for the specified format, which may be a pipeline
+// of comma-separated formats, each optionally followed by options, e.g.
+// "base64,hex:upper,group=2"
+func NewCodec(format string) (Codec, error) {
+	return newCodec(format, nil)
+}
+
+// NewCodecWithSchema returns a codec for the specified format with schema
+// support. In a pipeline, the schema applies to the last stage, and options
+// given in the format take precedence over it.
+func NewCodecWithSchema(format string, schema SchemaOptions) (Codec, error) {
+	return newCodec(format, &schema)
+}
+
+// newCodec creates the codecs of the stages of a format, passing the schema
+// to the last stage. Formats ending in a layer such as compression render the
+// unwrapped value as raw bytes.
+func newCodec(format string, schema *SchemaOptions) (Codec, error) {
+	stages, err := parseFormat(format)
+	if err != nil {
+		return nil, err
+	}
+
+	codecs := make([]Codec, len(stages))
+	for i, stage := range stages {
+		opts := codecOptions{format: stage.name, values: stage.values, raw: stage.raw}
+		if i == len(stages)-1 {
+			opts.schema = schema
+		}
+		if codecs[i], err = codecRegistry[stage.name].factory(opts); err != nil {
+			return nil, err
+		}
 	}
 
-	// Fall back to regular codec creation
-	return NewCodec(format)
+	// A layer ending the format unwraps the value rather than wrapping it again
+	if codecRegistry[stages[len(stages)-1].name].layer {
+		codecs = append(codecs, &RawCodec{})
+	}
+
+	return NewPipeline(codecs...), nil
 }
 
 // Register built-in codecs
@@ -42,12 +88,36 @@ func init() {
 		return &RawCodec{}
 	})
 
-	registerCodec("hex", func() Codec {
-		return &HexCodec{}
+	registerCodecWithOptions("hex", []string{"upper", "group"}, func(opts codecOptions) (Codec, error) {
+		upper, err := opts.bool("upper")
+		if err != nil {
+			return nil, err
+		}
+		group, err := opts.int("group", 0)
+		if err != nil {
+			return nil, err
+		}
+		return &HexCodec{upper: upper, group: group}, nil
 	})
 
-	registerCodec("base64", func() Codec {
-		return &Base64Codec{}
+	registerCodecWithOptions("base64", []string{"url", "nopad"}, func(opts codecOptions) (Codec, error) {
+		url, err := opts.bool("url")
+		if err != nil {
+			return nil, err
+		}
+		nopad, err := opts.bool("nopad")
+		if err != nil {
+			return nil, err
+		}
+
+		encoding := base64.StdEncoding
+		if url {
+			encoding = base64.URLEncoding
+		}
+		if nopad {
+			encoding = encoding.WithPadding(base64.NoPadding)
+		}
+		return &Base64Codec{encoding: encoding}, nil
 	})
 }
 
@@ -65,12 +135,15 @@ func (c *RawCodec) Encode(input []byte) ([]byte, error) {
 }
 
 // HexCodec handles messages in hexadecimal format
-type HexCodec struct{}
+type HexCodec struct {
+	upper bool // Use upper case digits
+	group int  // Separate groups of this many bytes with spaces, 0 for none
+}
 
 // Decode converts hex encoded bytes to raw bytes
 func (c *HexCodec) Decode(input []byte) ([]byte, error) {
-	// Trim any whitespace that might be present
-	hexStr := strings.TrimSpace(string(input))
+	// Drop any whitespace, such as the spaces between groups
+	hexStr := strings.Join(strings.Fields(string(input)), "")
 	output, err := hex.DecodeString(hexStr)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode hex: %w", err)
@@ -80,17 +153,34 @@ func (c *HexCodec) Decode(input []byte) ([]byte, error) {
 
 // Encode converts raw bytes to hex encoded bytes
 func (c *HexCodec) Encode(input []byte) ([]byte, error) {
-	return []byte(hex.EncodeToString(input)), nil
+	hexStr := hex.EncodeToString(input)
+	if c.upper {
+		hexStr = strings.ToUpper(hexStr)
+	}
+	if c.group <= 0 || len(input) <= c.group {
+		return []byte(hexStr), nil
+	}
+
+	var output strings.Builder
+	for i := 0; i < len(hexStr); i += c.group * 2 {
+		if i > 0 {
+			output.WriteByte(' ')
+		}
+		output.WriteString(hexStr[i:min(i+c.group*2, len(hexStr))])
+	}
+	return []byte(output.String()), nil
 }
 
 // Base64Codec handles messages in base64 format
-type Base64Codec struct{}
+type Base64Codec struct {
+	encoding *base64.Encoding // Defaults to standard base64 with padding
+}
 
 // Decode converts base64 encoded bytes to raw bytes
 func (c *Base64Codec) Decode(input []byte) ([]byte, error) {
 	// Trim any whitespace that might be present
 	b64Str := strings.TrimSpace(string(input))
-	output, err := base64.StdEncoding.DecodeString(b64Str)
+	output, err := c.base64Encoding().DecodeString(b64Str)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode base64: %w", err)
 	}
@@ -99,5 +189,13 @@ func (c *Base64Codec) Decode(input []byte) ([]byte, error) {
 
 // Encode converts raw bytes to base64 encoded bytes
 func (c *Base64Codec) Encode(input []byte) ([]byte, error) {
-	return []byte(base64.StdEncoding.EncodeToString(input)), nil
+	return []byte(c.base64Encoding().EncodeToString(input)), nil
+}
+
+// base64Encoding returns the base64 alphabet and padding in use
+func (c *Base64Codec) base64Encoding() *base64.Encoding {
+	if c.encoding == nil {
+		return base64.StdEncoding
+	}
+	return c.encoding
 }
@@ -0,0 +1,157 @@
+package format
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/snappy/xerial"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+)
+
+// Compression algorithms for payloads compressed inside record values,
+// independent of Kafka batch compression
+const (
+	CompressionNone   = "none"
+	CompressionAuto   = "auto" // Detect the algorithm from the magic bytes, consumer only
+	CompressionGzip   = "gzip"
+	CompressionZstd   = "zstd"
+	CompressionSnappy = "snappy"
+	CompressionLZ4    = "lz4"
+)
+
+// Compressions lists the supported compression settings
+var Compressions = []string{CompressionNone, CompressionAuto, CompressionGzip, CompressionZstd, CompressionSnappy, CompressionLZ4}
+
+// Magic bytes starting compressed payloads
+var (
+	gzipMagic         = []byte{0x1f, 0x8b}
+	zstdMagic         = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	lz4FrameMagic     = []byte{0x04, 0x22, 0x4d, 0x18}
+	snappyFramedMagic = []byte("\xff\x06\x00\x00sNaPpY")
+	snappyXerialMagic = []byte("\x82SNAPPY\x00")
+)
+
+// CompressionCodec compresses and decompresses payloads. It is a layer in a
+// pipeline, e.g. "gzip,json"; a format ending in it, e.g. "gzip", renders the
+// decompressed value as raw bytes. Snappy payloads are produced in the framing
+// format, and read in the framing format, the xerial format used by Java
+// clients, or as a raw block.
+type CompressionCodec struct {
+	algorithm string
+
+	zstdDecoder *zstd.Decoder // Created on first use
+	zstdEncoder *zstd.Encoder // Created on first use
+}
+
+func init() {
+	for _, algorithm := range []string{CompressionAuto, CompressionGzip, CompressionZstd, CompressionSnappy, CompressionLZ4} {
+		registerLayerCodec(algorithm, func() Codec {
+			return &CompressionCodec{algorithm: algorithm}
+		})
+	}
+}
+
+// Decode decompresses a payload. With auto-detection, payloads without known
+// magic bytes are returned unchanged.
+func (c *CompressionCodec) Decode(input []byte) ([]byte, error) {
+	algorithm := c.algorithm
+	if algorithm == CompressionAuto {
+		algorithm = detectCompression(input)
+		if algorithm == "" {
+			return input, nil
+		}
+	}
+
+	var output []byte
+	var err error
+	switch algorithm {
+	case CompressionGzip:
+		var reader *gzip.Reader
+		if reader, err = gzip.NewReader(bytes.NewReader(input)); err == nil {
+			output, err = io.ReadAll(reader)
+		}
+	case CompressionZstd:
+		if c.zstdDecoder == nil {
+			if c.zstdDecoder, err = zstd.NewReader(nil, zstd.WithDecoderConcurrency(1)); err != nil {
+				return nil, err
+			}
+		}
+		output, err = c.zstdDecoder.DecodeAll(input, nil)
+	case CompressionSnappy:
+		if bytes.HasPrefix(input, snappyFramedMagic) {
+			output, err = io.ReadAll(snappy.NewReader(bytes.NewReader(input)))
+		} else {
+			// Falls back to a raw block without the xerial header
+			output, err = xerial.Decode(input)
+		}
+	case CompressionLZ4:
+		output, err = io.ReadAll(lz4.NewReader(bytes.NewReader(input)))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress %s payload: %w", algorithm, err)
+	}
+	return output, nil
+}
+
+// Encode compresses a payload
+func (c *CompressionCodec) Encode(input []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	var writer io.WriteCloser
+	switch c.algorithm {
+	case CompressionAuto:
+		return nil, fmt.Errorf("compression must be given explicitly when producing, not %s", CompressionAuto)
+	case CompressionGzip:
+		writer = gzip.NewWriter(&buf)
+	case CompressionZstd:
+		if c.zstdEncoder == nil {
+			var err error
+			if c.zstdEncoder, err = zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1)); err != nil {
+				return nil, err
+			}
+		}
+		return c.zstdEncoder.EncodeAll(input, nil), nil
+	case CompressionSnappy:
+		writer = snappy.NewBufferedWriter(&buf)
+	case CompressionLZ4:
+		writer = lz4.NewWriter(&buf)
+	}
+
+	if _, err := writer.Write(input); err != nil {
+		return nil, fmt.Errorf("failed to compress %s payload: %w", c.algorithm, err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress %s payload: %w", c.algorithm, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// detectCompression returns the compression algorithm identified by the
+// magic bytes of a payload, or "" for none
+func detectCompression(input []byte) string {
+	switch {
+	case bytes.HasPrefix(input, gzipMagic):
+		return CompressionGzip
+	case bytes.HasPrefix(input, zstdMagic):
+		return CompressionZstd
+	case bytes.HasPrefix(input, lz4FrameMagic):
+		return CompressionLZ4
+	case bytes.HasPrefix(input, snappyFramedMagic), bytes.HasPrefix(input, snappyXerialMagic):
+		return CompressionSnappy
+	default:
+		return ""
+	}
+}
+
+// WithCompression prepends a compression stage to a format, so that payloads
+// are decompressed before the format renders them and compressed after it
+// parses input. With CompressionNone or "", the format is returned unchanged.
+func WithCompression(format, algorithm string) string {
+	if algorithm == CompressionNone || algorithm == "" {
+		return format
+	}
+	return algorithm + PipelineSeparator + format
+}
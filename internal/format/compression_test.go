@@ -0,0 +1,216 @@
+package format
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/snappy/xerial"
+)
+
+func TestCompressionCodecRoundTrip(t *testing.T) {
+	payload := bytes.Repeat([]byte("kafkadog "), 100)
+
+	tests := []struct {
+		algorithm     string
+		expectedMagic []byte
+	}{
+		{CompressionGzip, gzipMagic},
+		{CompressionZstd, zstdMagic},
+		{CompressionSnappy, snappyFramedMagic},
+		{CompressionLZ4, lz4FrameMagic},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			codec, err := NewCodec(tt.algorithm)
+			if err != nil {
+				t.Fatalf("Failed to create codec: %v", err)
+			}
+
+			// Produced values are compressed, consumed values decompressed
+			compressed, err := codec.Decode(payload)
+			if err != nil {
+				t.Fatalf("Failed to compress: %v", err)
+			}
+			if !bytes.HasPrefix(compressed, tt.expectedMagic) {
+				t.Errorf("Expected payload starting with %x, got %x", tt.expectedMagic, compressed)
+			}
+
+			output, err := codec.Encode(compressed)
+			if err != nil {
+				t.Fatalf("Failed to decompress: %v", err)
+			}
+			if !bytes.Equal(output, payload) {
+				t.Errorf("Expected %q, got %q", payload, output)
+			}
+
+			// Auto-detection finds the same algorithm from the magic bytes
+			auto, err := NewCodec(CompressionAuto)
+			if err != nil {
+				t.Fatalf("Failed to create codec: %v", err)
+			}
+			output, err = auto.Encode(compressed)
+			if err != nil {
+				t.Fatalf("Failed to decompress with auto-detection: %v", err)
+			}
+			if !bytes.Equal(output, payload) {
+				t.Errorf("Expected %q with auto-detection, got %q", payload, output)
+			}
+		})
+	}
+}
+
+func TestCompressionCodecSnappyFormats(t *testing.T) {
+	payload := []byte("hello snappy")
+
+	tests := []struct {
+		name      string
+		algorithm string
+		input     []byte
+	}{
+		{"raw block", CompressionSnappy, snappy.Encode(nil, payload)},
+		{"xerial", CompressionSnappy, xerial.Encode(nil, payload)},
+		{"xerial detected", CompressionAuto, xerial.Encode(nil, payload)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codec, err := NewCodec(tt.algorithm)
+			if err != nil {
+				t.Fatalf("Failed to create codec: %v", err)
+			}
+			output, err := codec.Encode(tt.input)
+			if err != nil {
+				t.Fatalf("Failed to decompress: %v", err)
+			}
+			if !bytes.Equal(output, payload) {
+				t.Errorf("Expected %q, got %q", payload, output)
+			}
+		})
+	}
+}
+
+func TestCompressionCodecErrors(t *testing.T) {
+	if _, err := NewCodec("brotli"); err == nil {
+		t.Errorf("Expected error for unknown compression, got nil")
+	}
+
+	auto, err := NewCodec(CompressionAuto)
+	if err != nil {
+		t.Fatalf("Failed to create codec: %v", err)
+	}
+	if _, err := auto.Decode([]byte("payload")); err == nil {
+		t.Errorf("Expected error compressing with auto-detection, got nil")
+	}
+
+	// Uncompressed payloads pass through auto-detection unchanged
+	output, err := auto.Encode([]byte("plain"))
+	if err != nil {
+		t.Fatalf("Failed to pass through uncompressed payload: %v", err)
+	}
+	if string(output) != "plain" {
+		t.Errorf("Expected 'plain', got %q", output)
+	}
+
+	// A corrupt payload with valid magic bytes
+	if _, err := auto.Encode(append(append([]byte{}, gzipMagic...), 0x00, 0x01)); err == nil {
+		t.Errorf("Expected error for corrupt gzip payload, got nil")
+	}
+
+	gzip, err := NewCodec(CompressionGzip)
+	if err != nil {
+		t.Fatalf("Failed to create codec: %v", err)
+	}
+	if _, err := gzip.Encode([]byte("plain")); err == nil {
+		t.Errorf("Expected error decompressing an uncompressed payload, got nil")
+	}
+}
+
+func TestWithCompression(t *testing.T) {
+	if format := WithCompression("json", CompressionNone); format != "json" {
+		t.Errorf("Expected format to be returned unchanged without compression, got %s", format)
+	}
+
+	codec, err := NewCodec(WithCompression("json", CompressionZstd))
+	if err != nil {
+		t.Fatalf("Failed to create codec: %v", err)
+	}
+	if !EncodesJSON(codec) {
+		t.Errorf("Expected wrapped JSON codec to encode JSON")
+	}
+
+	data, err := codec.Decode([]byte(`{ "id": "u1" }`))
+	if err != nil {
+		t.Fatalf("Failed to decode input: %v", err)
+	}
+	if !bytes.HasPrefix(data, zstdMagic) {
+		t.Errorf("Expected zstd payload, got %x", data)
+	}
+
+	output, err := codec.Encode(data)
+	if err != nil {
+		t.Fatalf("Failed to encode payload: %v", err)
+	}
+	if string(output) != `{"id":"u1"}` {
+		t.Errorf("Expected {\"id\":\"u1\"}, got %s", output)
+	}
+
+	if _, err := NewCodec(WithCompression("json", "brotli")); err == nil {
+		t.Errorf("Expected error for unknown compression, got nil")
+	}
+}
+
+func TestCompressionStages(t *testing.T) {
+	gzipped, err := NewCodec(CompressionGzip)
+	if err != nil {
+		t.Fatalf("Failed to create codec: %v", err)
+	}
+	compressed, err := gzipped.Decode([]byte("hello"))
+	if err != nil {
+		t.Fatalf("Failed to compress: %v", err)
+	}
+
+	tests := []struct {
+		format string
+		input  []byte
+	}{
+		{"gzip", compressed},
+		{"auto", compressed},
+		{"auto", []byte("hello")},
+		{"base64,gzip", []byte(base64.StdEncoding.EncodeToString(compressed))},
+		{"auto,raw", compressed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			codec, err := NewCodec(tt.format)
+			if err != nil {
+				t.Fatalf("Failed to create codec: %v", err)
+			}
+
+			// A format ending in compression prints the decompressed value
+			output, err := codec.Encode(tt.input)
+			if err != nil {
+				t.Fatalf("Failed to encode: %v", err)
+			}
+			if string(output) != "hello" {
+				t.Errorf("Expected 'hello', got %q", output)
+			}
+		})
+	}
+
+	// Input is compressed when producing
+	codec, err := NewCodec("gzip")
+	if err != nil {
+		t.Fatalf("Failed to create codec: %v", err)
+	}
+	data, err := codec.Decode([]byte("hello"))
+	if err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+	if !bytes.HasPrefix(data, gzipMagic) {
+		t.Errorf("Expected gzip payload, got %x", data)
+	}
+}
@@ -0,0 +1,195 @@
+package format
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// Operations requested from exec codec commands
+const (
+	execOpEncode = 'e' // Transform record bytes for display
+	execOpDecode = 'd' // Transform input into record bytes
+)
+
+// execStatusOK is the response status of a successful transformation
+const execStatusOK = 0
+
+// maxExecFrameSize limits the payload size of a single frame
+const maxExecFrameSize = 256 << 20
+
+// execRequestTimeout limits how long a command may take to respond, so that a
+// hanging command cannot block kafkadog indefinitely
+const execRequestTimeout = 30 * time.Second
+
+// ExecCodec pipes values through a long-running external command, started on
+// first use. Requests written to the command's stdin are an operation byte
+// ('e' to encode, 'd' to decode), a 4-byte big-endian payload length and the
+// payload. Responses read from its stdout are a status byte (0 for success),
+// a 4-byte big-endian length and the transformed payload, or an error message
+// when the status is not 0. A command not responding within the request
+// timeout is stopped.
+type ExecCodec struct {
+	command string        // Shell command line
+	timeout time.Duration // Time allowed for each request
+
+	mu     sync.Mutex
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	pipe   io.ReadCloser // Read end of the command's stdout
+	stdout *bufio.Reader
+	err    error // Set once the command has failed
+}
+
+func init() {
+	registerCodecWithRawOptions("exec", func(opts codecOptions) (Codec, error) {
+		if opts.raw == "" {
+			return nil, fmt.Errorf("exec requires a command, e.g. 'exec:./decoder --flag'")
+		}
+		return NewExecCodec(opts.raw), nil
+	})
+}
+
+// NewExecCodec creates a codec running the shell command line
+func NewExecCodec(command string) *ExecCodec {
+	return &ExecCodec{command: command, timeout: execRequestTimeout}
+}
+
+// Encode has the command transform record bytes for display
+func (c *ExecCodec) Encode(input []byte) ([]byte, error) {
+	return c.transform(execOpEncode, input)
+}
+
+// Decode has the command transform input into record bytes
+func (c *ExecCodec) Decode(input []byte) ([]byte, error) {
+	return c.transform(execOpDecode, input)
+}
+
+// Close closes the command's stdin and waits for it to exit, stopping it if
+// it does not exit within the request timeout
+func (c *ExecCodec) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cmd == nil {
+		return nil
+	}
+	c.stdin.Close()
+	cmd := c.cmd
+	timer := time.AfterFunc(c.timeout, func() { killProcessGroup(cmd) })
+	err := c.cmd.Wait()
+	timer.Stop()
+	c.cmd = nil
+	if c.err == nil {
+		c.err = fmt.Errorf("exec codec '%s' is closed", c.command)
+	}
+	return err
+}
+
+// transform sends a request to the command and returns the payload of its
+// response. Errors reported by the command fail only the request, while
+// protocol and I/O errors stop the command and fail all later requests.
+func (c *ExecCodec) transform(op byte, input []byte) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.cmd == nil {
+		if err := c.start(); err != nil {
+			c.err = err
+			return nil, err
+		}
+	}
+
+	// Stopping the command unblocks a request it does not respond to
+	cmd, pipe := c.cmd, c.pipe
+	timer := time.AfterFunc(c.timeout, func() {
+		killProcessGroup(cmd)
+		pipe.Close()
+	})
+	status, payload, err := c.roundTrip(op, input)
+	if !timer.Stop() {
+		err = fmt.Errorf("no response within %s", c.timeout)
+	}
+	if err != nil {
+		c.err = fmt.Errorf("exec codec '%s' failed: %w", c.command, err)
+		c.kill()
+		return nil, c.err
+	}
+	if status != execStatusOK {
+		return nil, fmt.Errorf("exec codec '%s': %s", c.command, payload)
+	}
+	return payload, nil
+}
+
+// start runs the command with its stderr passed through
+func (c *ExecCodec) start() error {
+	cmd := exec.Command("sh", "-c", c.command)
+	cmd.Stderr = os.Stderr
+	setProcessGroup(cmd)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("failed to start exec codec '%s': %w", c.command, err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to start exec codec '%s': %w", c.command, err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start exec codec '%s': %w", c.command, err)
+	}
+
+	c.cmd = cmd
+	c.stdin = stdin
+	c.pipe = stdout
+	c.stdout = bufio.NewReader(stdout)
+	return nil
+}
+
+// kill stops a failed command
+func (c *ExecCodec) kill() {
+	c.stdin.Close()
+	killProcessGroup(c.cmd)
+	c.cmd.Wait()
+	c.cmd = nil
+}
+
+// roundTrip writes a request frame and reads the response frame
+func (c *ExecCodec) roundTrip(op byte, input []byte) (byte, []byte, error) {
+	if len(input) > maxExecFrameSize {
+		return 0, nil, fmt.Errorf("payload of %d bytes exceeds the frame size limit of %d bytes", len(input), maxExecFrameSize)
+	}
+
+	request := make([]byte, 5, 5+len(input))
+	request[0] = op
+	binary.BigEndian.PutUint32(request[1:], uint32(len(input)))
+	if _, err := c.stdin.Write(append(request, input...)); err != nil {
+		return 0, nil, fmt.Errorf("failed to write request: %w", err)
+	}
+
+	var header [5]byte
+	if _, err := io.ReadFull(c.stdout, header[:]); err != nil {
+		if err == io.EOF {
+			return 0, nil, fmt.Errorf("command exited without responding")
+		}
+		return 0, nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	length := binary.BigEndian.Uint32(header[1:])
+	if length > maxExecFrameSize {
+		return 0, nil, fmt.Errorf("response of %d bytes exceeds the frame size limit of %d bytes", length, maxExecFrameSize)
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.stdout, payload); err != nil {
+		return 0, nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	return header[0], payload, nil
+}
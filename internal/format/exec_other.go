@@ -0,0 +1,13 @@
+//go:build !unix
+
+package format
+
+import "os/exec"
+
+// setProcessGroup is a no-op without process groups
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// killProcessGroup stops the command
+func killProcessGroup(cmd *exec.Cmd) {
+	cmd.Process.Kill()
+}
@@ -0,0 +1,196 @@
+package format
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// execPluginEnv makes the test binary act as an exec codec command
+const execPluginEnv = "KAFKADOG_TEST_EXEC_PLUGIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(execPluginEnv) != "" {
+		runTestPlugin()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runTestPlugin serves exec codec requests on stdin: encoding upper-cases
+// the payload and decoding lower-cases it. The payloads "count", "fail",
+// "crash" and "hang" return the number of requests served, an error, exit, or
+// stop responding.
+func runTestPlugin() {
+	in := bufio.NewReader(os.Stdin)
+	out := bufio.NewWriter(os.Stdout)
+	for requests := 1; ; requests++ {
+		var header [5]byte
+		if _, err := io.ReadFull(in, header[:]); err != nil {
+			return
+		}
+		payload := make([]byte, binary.BigEndian.Uint32(header[1:]))
+		if _, err := io.ReadFull(in, payload); err != nil {
+			return
+		}
+
+		status := byte(0)
+		switch {
+		case string(payload) == "count":
+			payload = []byte(strconv.Itoa(requests))
+		case string(payload) == "fail":
+			status, payload = 1, []byte("cannot transform 'fail'")
+		case string(payload) == "crash":
+			os.Exit(3)
+		case string(payload) == "hang":
+			time.Sleep(10 * time.Second)
+		case header[0] == 'e':
+			payload = bytes.ToUpper(payload)
+		default:
+			payload = bytes.ToLower(payload)
+		}
+
+		response := []byte{status, 0, 0, 0, 0}
+		binary.BigEndian.PutUint32(response[1:], uint32(len(payload)))
+		out.Write(append(response, payload...))
+		out.Flush()
+	}
+}
+
+// testPluginCommand returns the command line running the test plugin
+func testPluginCommand() string {
+	return fmt.Sprintf("%s=1 '%s'", execPluginEnv, os.Args[0])
+}
+
+func TestExecCodec(t *testing.T) {
+	codec, err := NewCodec("exec:" + testPluginCommand())
+	if err != nil {
+		t.Fatalf("Failed to create codec: %v", err)
+	}
+	t.Cleanup(func() { codec.(*ExecCodec).Close() })
+
+	tests := []struct {
+		name        string
+		transform   func([]byte) ([]byte, error)
+		input       string
+		expected    string
+		expectError bool
+	}{
+		{"encode", codec.Encode, "Hello", "HELLO", false},
+		{"decode", codec.Decode, "Hello", "hello", false},
+		{"empty payload", codec.Encode, "", "", false},
+		{"error reported by the command", codec.Encode, "fail", "", true},
+		{"same process after an error", codec.Encode, "count", "5", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := tt.transform([]byte(tt.input))
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to transform: %v", err)
+			}
+			if string(output) != tt.expected {
+				t.Errorf("Expected '%s', got '%s'", tt.expected, output)
+			}
+		})
+	}
+}
+
+func TestExecCodecInPipeline(t *testing.T) {
+	// A command containing commas, followed by another stage
+	codec, err := NewCodec("base64,exec:" + testPluginCommand() + " a,b,raw")
+	if err != nil {
+		t.Fatalf("Failed to create codec: %v", err)
+	}
+	t.Cleanup(func() { codec.(*Pipeline).stages[1].(*ExecCodec).Close() })
+
+	// As a layer, the command encodes when producing and decodes when consuming
+	data, err := codec.Decode([]byte("Hello"))
+	if err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+	if string(data) != "SEVMTE8=" {
+		t.Errorf("Expected 'SEVMTE8=', got '%s'", data)
+	}
+
+	output, err := codec.Encode(data)
+	if err != nil {
+		t.Fatalf("Failed to encode: %v", err)
+	}
+	if string(output) != "hello" {
+		t.Errorf("Expected 'hello', got '%s'", output)
+	}
+}
+
+func TestExecCodecFailures(t *testing.T) {
+	t.Run("command exits", func(t *testing.T) {
+		codec := NewExecCodec(testPluginCommand())
+		t.Cleanup(func() { codec.Close() })
+
+		if _, err := codec.Encode([]byte("crash")); err == nil {
+			t.Fatalf("Expected error, got nil")
+		}
+		// The command is not restarted
+		if _, err := codec.Encode([]byte("hello")); err == nil || !strings.Contains(err.Error(), "exited") {
+			t.Errorf("Expected error about the exited command, got %v", err)
+		}
+	})
+
+	t.Run("command not responding", func(t *testing.T) {
+		codec := NewExecCodec(testPluginCommand())
+		codec.timeout = 100 * time.Millisecond
+		t.Cleanup(func() { codec.Close() })
+
+		if _, err := codec.Encode([]byte("hang")); err == nil || !strings.Contains(err.Error(), "no response") {
+			t.Fatalf("Expected timeout error, got %v", err)
+		}
+		if _, err := codec.Encode([]byte("hello")); err == nil {
+			t.Errorf("Expected error after the timeout, got nil")
+		}
+	})
+
+	t.Run("closed codec", func(t *testing.T) {
+		codec, err := NewCodec("base64,exec:" + testPluginCommand())
+		if err != nil {
+			t.Fatalf("Failed to create codec: %v", err)
+		}
+		if _, err := codec.Encode([]byte("aGVsbG8=")); err != nil {
+			t.Fatalf("Failed to encode: %v", err)
+		}
+
+		if err := CloseCodec(codec); err != nil {
+			t.Errorf("Expected command to exit cleanly, got %v", err)
+		}
+		if _, err := codec.Encode([]byte("aGVsbG8=")); err == nil {
+			t.Errorf("Expected error after closing, got nil")
+		}
+	})
+
+	t.Run("command not found", func(t *testing.T) {
+		codec := NewExecCodec("/nonexistent/kafkadog-plugin")
+		t.Cleanup(func() { codec.Close() })
+
+		if _, err := codec.Encode([]byte("hello")); err == nil {
+			t.Errorf("Expected error, got nil")
+		}
+	})
+
+	t.Run("missing command", func(t *testing.T) {
+		if _, err := NewCodec("exec:"); err == nil {
+			t.Errorf("Expected error, got nil")
+		}
+	})
+}
@@ -0,0 +1,189 @@
+package format
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// JSONCodec handles JSON values, compacting or indenting them for display and
+// optionally validating them against a JSON Schema
+type JSONCodec struct {
+	pretty bool               // Indent consumed values instead of compacting them
+	schema *jsonschema.Schema // Schema to validate values against, nil for none
+	warn   io.Writer          // Where schema violations of consumed values are reported
+}
+
+func init() {
+	for _, name := range []string{"json", "json-pretty"} {
+		registerCodecWithOptions(name, []string{"schema"}, func(opts codecOptions) (Codec, error) {
+			pretty := name == "json-pretty"
+			if schemaFile := opts.string("schema", opts.baseSchema().JSONSchemaFile); schemaFile != "" {
+				return NewJSONSchemaCodec(schemaFile, pretty)
+			}
+			return &JSONCodec{pretty: pretty}, nil
+		})
+	}
+}
+
+// NewJSONSchemaCodec creates a JSON codec validating values against the JSON
+// Schema in the given file
+func NewJSONSchemaCodec(schemaFile string, pretty bool) (*JSONCodec, error) {
+	schema, err := jsonschema.NewCompiler().Compile(schemaFile)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON Schema %s: %w", schemaFile, err)
+	}
+
+	return &JSONCodec{
+		pretty: pretty,
+		schema: schema,
+		warn:   os.Stderr,
+	}, nil
+}
+
+// EncodesJSON reports that the codec renders values as JSON
+func (c *JSONCodec) EncodesJSON() bool {
+	return true
+}
+
+// Encode compacts or indents a JSON value. Values violating the schema are
+// still returned, with the violation reported as a warning.
+func (c *JSONCodec) Encode(input []byte) ([]byte, error) {
+	violation, err := c.validate(input)
+	if err != nil {
+		return nil, err
+	}
+	if violation != nil {
+		fmt.Fprintf(c.warn, "Warning: %v\n", violation)
+	}
+
+	var buf bytes.Buffer
+	if c.pretty {
+		if err := json.Indent(&buf, input, "", "  "); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+	} else if err := json.Compact(&buf, input); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode compacts a JSON value, rejecting values that violate the schema
+func (c *JSONCodec) Decode(input []byte) ([]byte, error) {
+	violation, err := c.validate(input)
+	if err != nil {
+		return nil, err
+	}
+	if violation != nil {
+		return nil, violation
+	}
+
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, input); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// validate checks a JSON value against the schema, if any. Schema violations
+// are returned separately from errors parsing the value.
+func (c *JSONCodec) validate(input []byte) (violation error, err error) {
+	if c.schema == nil {
+		return nil, nil
+	}
+
+	value, err := jsonschema.UnmarshalJSON(bytes.NewReader(input))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	if err := c.schema.Validate(value); err != nil {
+		return fmt.Errorf("value violates JSON Schema: %w", err), nil
+	}
+	return nil, nil
+}
+
+// marshalJSONValue renders a value decoded from a binary format as JSON. Maps
+// with non-string keys are keyed by the string form of their keys.
+func marshalJSONValue(value any) ([]byte, error) {
+	output, err := json.Marshal(jsonCompatible(value))
+	if err != nil {
+		return nil, fmt.Errorf("failed to render value as JSON: %w", err)
+	}
+	return output, nil
+}
+
+// jsonCompatible converts maps with non-string keys, recursively, into maps
+// that encoding/json can marshal
+func jsonCompatible(value any) any {
+	switch v := value.(type) {
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for key, element := range v {
+			m[fmt.Sprint(key)] = jsonCompatible(element)
+		}
+		return m
+	case map[string]any:
+		for key, element := range v {
+			v[key] = jsonCompatible(element)
+		}
+		return v
+	case []any:
+		for i, element := range v {
+			v[i] = jsonCompatible(element)
+		}
+		return v
+	default:
+		return value
+	}
+}
+
+// unmarshalJSONValue parses a single JSON value for encoding in a binary
+// format. Integral numbers are kept as integers rather than floats.
+func unmarshalJSONValue(input []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(input))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("invalid JSON: unexpected data after the value")
+	}
+
+	return convertJSONNumbers(value), nil
+}
+
+// convertJSONNumbers replaces json.Number values with int64, uint64 or
+// float64 values
+func convertJSONNumbers(value any) any {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		if u, err := strconv.ParseUint(v.String(), 10, 64); err == nil {
+			return u
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]any:
+		for key, element := range v {
+			v[key] = convertJSONNumbers(element)
+		}
+		return v
+	case []any:
+		for i, element := range v {
+			v[i] = convertJSONNumbers(element)
+		}
+		return v
+	default:
+		return value
+	}
+}
@@ -0,0 +1,191 @@
+package format
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testJSONSchema = `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "type": "object",
+  "properties": {
+    "id": {"type": "string"},
+    "age": {"type": "integer", "minimum": 0}
+  },
+  "required": ["id"]
+}`
+
+// newTestJSONSchemaCodec creates a JSON codec validating against the test
+// schema, with warnings written to the returned buffer
+func newTestJSONSchemaCodec(t *testing.T, pretty bool) (*JSONCodec, *bytes.Buffer) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "schema.json")
+	if err := os.WriteFile(path, []byte(testJSONSchema), 0o600); err != nil {
+		t.Fatalf("Failed to write JSON Schema: %v", err)
+	}
+
+	codec, err := NewJSONSchemaCodec(path, pretty)
+	if err != nil {
+		t.Fatalf("Failed to create codec: %v", err)
+	}
+	var warnings bytes.Buffer
+	codec.warn = &warnings
+	return codec, &warnings
+}
+
+func TestJSONCodecEncode(t *testing.T) {
+	tests := []struct {
+		name        string
+		format      string
+		input       string
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "compact",
+			format:   "json",
+			input:    "{\n  \"id\": \"u1\",\n  \"tags\": [1, 2]\n}",
+			expected: `{"id":"u1","tags":[1,2]}`,
+		},
+		{
+			name:     "pretty",
+			format:   "json-pretty",
+			input:    `{"id":"u1","tags":[1,2]}`,
+			expected: "{\n  \"id\": \"u1\",\n  \"tags\": [\n    1,\n    2\n  ]\n}",
+		},
+		{
+			name:     "scalar",
+			format:   "json",
+			input:    ` "text" `,
+			expected: `"text"`,
+		},
+		{
+			name:        "invalid JSON",
+			format:      "json",
+			input:       `{"id":`,
+			expectError: true,
+		},
+		{
+			name:        "not JSON",
+			format:      "json-pretty",
+			input:       `plain text`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codec, err := NewCodec(tt.format)
+			if err != nil {
+				t.Fatalf("Failed to create codec: %v", err)
+			}
+
+			output, err := codec.Encode([]byte(tt.input))
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if string(output) != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, output)
+			}
+		})
+	}
+}
+
+func TestJSONSchemaCodec(t *testing.T) {
+	tests := []struct {
+		name            string
+		input           string
+		expected        string // Expected output of both Encode and Decode
+		expectViolation bool
+		expectError     bool
+	}{
+		{
+			name:     "valid value",
+			input:    `{"id": "u1", "age": 42}`,
+			expected: `{"id":"u1","age":42}`,
+		},
+		{
+			name:            "missing required property",
+			input:           `{"age": 42}`,
+			expected:        `{"age":42}`,
+			expectViolation: true,
+		},
+		{
+			name:            "wrong type",
+			input:           `{"id": "u1", "age": -1}`,
+			expected:        `{"id":"u1","age":-1}`,
+			expectViolation: true,
+		},
+		{
+			name:        "invalid JSON",
+			input:       `{"id":`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codec, warnings := newTestJSONSchemaCodec(t, false)
+
+			// Consuming reports violations but still prints the value
+			output, err := codec.Encode([]byte(tt.input))
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error, got nil")
+				}
+			} else if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			} else if string(output) != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, output)
+			}
+			if tt.expectViolation && !strings.Contains(warnings.String(), "violates JSON Schema") {
+				t.Errorf("Expected a schema violation warning, got %q", warnings.String())
+			}
+			if !tt.expectViolation && warnings.Len() > 0 {
+				t.Errorf("Expected no warnings, got %q", warnings.String())
+			}
+
+			// Producing rejects violations
+			output, err = codec.Decode([]byte(tt.input))
+			if tt.expectError || tt.expectViolation {
+				if err == nil {
+					t.Errorf("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if string(output) != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, output)
+			}
+		})
+	}
+}
+
+func TestNewJSONSchemaCodecErrors(t *testing.T) {
+	invalid := filepath.Join(t.TempDir(), "invalid.json")
+	if err := os.WriteFile(invalid, []byte(`{"type": 42}`), 0o600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	for name, path := range map[string]string{
+		"missing schema": filepath.Join(t.TempDir(), "missing.json"),
+		"invalid schema": invalid,
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := NewCodecWithSchema("json", SchemaOptions{JSONSchemaFile: path}); err == nil {
+				t.Errorf("Expected error, got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package format
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// MsgpackCodec handles MessagePack values, rendered as JSON
+type MsgpackCodec struct{}
+
+func init() {
+	registerCodec("msgpack", func() Codec {
+		return &MsgpackCodec{}
+	})
+}
+
+// EncodesJSON reports that the codec renders values as JSON
+func (c *MsgpackCodec) EncodesJSON() bool {
+	return true
+}
+
+// Encode transforms a MessagePack value to JSON
+func (c *MsgpackCodec) Encode(input []byte) ([]byte, error) {
+	reader := bytes.NewReader(input)
+	decoder := msgpack.NewDecoder(reader)
+	// Map keys need not be strings
+	decoder.SetMapDecoder(func(d *msgpack.Decoder) (any, error) {
+		return d.DecodeUntypedMap()
+	})
+	value, err := decoder.DecodeInterface()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode MessagePack: %w", err)
+	}
+	if reader.Len() > 0 {
+		return nil, fmt.Errorf("failed to decode MessagePack: unexpected data after the value")
+	}
+
+	return marshalJSONValue(value)
+}
+
+// Decode transforms JSON to a MessagePack value
+func (c *MsgpackCodec) Decode(input []byte) ([]byte, error) {
+	value, err := unmarshalJSONValue(input)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	encoder := msgpack.NewEncoder(&buf)
+	encoder.SetSortMapKeys(true)
+	encoder.UseCompactInts(true)
+	if err := encoder.Encode(value); err != nil {
+		return nil, fmt.Errorf("failed to encode MessagePack: %w", err)
+	}
+	return buf.Bytes(), nil
+}
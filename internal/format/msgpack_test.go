@@ -0,0 +1,112 @@
+package format
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMsgpackCodecRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []byte
+	}{
+		{"integer", `42`, []byte{0x2a}},
+		{"negative integer", `-1`, []byte{0xff}},
+		{"float", `1.5`, []byte{0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}},
+		{"string", `"hi"`, []byte{0xa2, 'h', 'i'}},
+		{"array", `[true,null]`, []byte{0x92, 0xc3, 0xc0}},
+		{"map with sorted keys", `{"b":1,"a":2}`, []byte{0x82, 0xa1, 'a', 0x02, 0xa1, 'b', 0x01}},
+	}
+
+	codec, err := NewCodec("msgpack")
+	if err != nil {
+		t.Fatalf("Failed to create codec: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := codec.Decode([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("Failed to decode JSON: %v", err)
+			}
+			if !bytes.Equal(data, tt.expected) {
+				t.Errorf("Expected %x, got %x", tt.expected, data)
+			}
+
+			output, err := codec.Encode(data)
+			if err != nil {
+				t.Fatalf("Failed to encode data: %v", err)
+			}
+			if !jsonEqual(t, output, []byte(tt.input)) {
+				t.Errorf("Expected %s, got %s", tt.input, output)
+			}
+		})
+	}
+}
+
+func TestMsgpackCodecEncode(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       []byte
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "integer map keys",
+			input:    []byte{0x81, 0x01, 0xa1, 'a'},
+			expected: `{"1":"a"}`,
+		},
+		{
+			name:     "binary",
+			input:    []byte{0xc4, 0x02, 0x01, 0x02},
+			expected: `"AQI="`,
+		},
+		{
+			name:        "truncated",
+			input:       []byte{0x92, 0xc3},
+			expectError: true,
+		},
+		{
+			name:        "trailing data",
+			input:       []byte{0x2a, 0x2a},
+			expectError: true,
+		},
+	}
+
+	codec, err := NewCodec("msgpack")
+	if err != nil {
+		t.Fatalf("Failed to create codec: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := codec.Encode(tt.input)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to encode data: %v", err)
+			}
+			if string(output) != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, output)
+			}
+		})
+	}
+}
+
+func TestMsgpackCodecDecodeInvalidJSON(t *testing.T) {
+	codec, err := NewCodec("msgpack")
+	if err != nil {
+		t.Fatalf("Failed to create codec: %v", err)
+	}
+
+	for _, input := range []string{``, `{"a":`, `1 2`} {
+		if _, err := codec.Decode([]byte(input)); err == nil {
+			t.Errorf("Expected error for input '%s', got nil", input)
+		}
+	}
+}
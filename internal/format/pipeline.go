@@ -0,0 +1,66 @@
+package format
+
+import "errors"
+
+// PipelineSeparator separates the stages of a codec pipeline, e.g.
+// "base64,gzip,protobuf"
+const PipelineSeparator = ","
+
+// Pipeline chains codecs. Every stage but the last is a layer the value is
+// wrapped in, outermost first, and the last stage renders the unwrapped
+// value. Encode runs the stages in order, decoding each layer and encoding
+// with the last stage; Decode runs them in reverse.
+type Pipeline struct {
+	stages []Codec
+}
+
+// NewPipeline chains the codecs into a pipeline. A single codec is returned
+// unchanged.
+func NewPipeline(stages ...Codec) Codec {
+	if len(stages) == 1 {
+		return stages[0]
+	}
+	return &Pipeline{stages: stages}
+}
+
+// EncodesJSON reports whether the last stage renders values as JSON
+func (p *Pipeline) EncodesJSON() bool {
+	return EncodesJSON(p.stages[len(p.stages)-1])
+}
+
+// Encode unwraps the layers of a value in order and renders the result with
+// the last stage
+func (p *Pipeline) Encode(input []byte) ([]byte, error) {
+	data := input
+	for _, layer := range p.stages[:len(p.stages)-1] {
+		var err error
+		if data, err = layer.Decode(data); err != nil {
+			return nil, err
+		}
+	}
+	return p.stages[len(p.stages)-1].Encode(data)
+}
+
+// Decode parses input with the last stage and wraps the result in the layers
+// in reverse order
+func (p *Pipeline) Decode(input []byte) ([]byte, error) {
+	data, err := p.stages[len(p.stages)-1].Decode(input)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(p.stages) - 2; i >= 0; i-- {
+		if data, err = p.stages[i].Encode(data); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// Close closes the stages holding resources, such as exec commands
+func (p *Pipeline) Close() error {
+	var errs []error
+	for _, stage := range p.stages {
+		errs = append(errs, CloseCodec(stage))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,128 @@
+package format
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPipelineRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		input    string
+		expected string // Expected stored value, empty to only check the round trip
+	}{
+		{
+			name:     "base64 wrapped hex",
+			format:   "base64,hex",
+			input:    "6b6166",
+			expected: "a2Fm",
+		},
+		{
+			name:   "compressed JSON",
+			format: "gzip,json",
+			input:  `{"id":"u1"}`,
+		},
+		{
+			name:   "base64 wrapped compressed MessagePack",
+			format: "base64, zstd, msgpack",
+			input:  `{"id":"u1","n":[1,2]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codec, err := NewCodec(tt.format)
+			if err != nil {
+				t.Fatalf("Failed to create codec: %v", err)
+			}
+
+			data, err := codec.Decode([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("Failed to decode input: %v", err)
+			}
+			if tt.expected != "" && string(data) != tt.expected {
+				t.Errorf("Expected stored value %q, got %q", tt.expected, data)
+			}
+
+			output, err := codec.Encode(data)
+			if err != nil {
+				t.Fatalf("Failed to encode data: %v", err)
+			}
+			if string(output) != tt.input && !jsonEqual(t, output, []byte(tt.input)) {
+				t.Errorf("Expected %s, got %s", tt.input, output)
+			}
+		})
+	}
+}
+
+func TestPipelineWithSchema(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "events.proto"), []byte(testProto), 0o600); err != nil {
+		t.Fatalf("Failed to write proto file: %v", err)
+	}
+	schema := SchemaOptions{ImportDirs: []string{dir}, MessageType: "events.UserEvent"}
+
+	codec, err := NewCodecWithSchema("base64,gzip,protobuf", schema)
+	if err != nil {
+		t.Fatalf("Failed to create codec: %v", err)
+	}
+	if !EncodesJSON(codec) {
+		t.Errorf("Expected pipeline ending in protobuf to encode JSON")
+	}
+
+	input := `{"user":{"id":"u1"},"kind":"KIND_LOGIN"}`
+	data, err := codec.Decode([]byte(input))
+	if err != nil {
+		t.Fatalf("Failed to decode JSON: %v", err)
+	}
+
+	// The stored value is base64 text of the compressed message
+	layers, err := NewCodec("base64,gzip,raw")
+	if err != nil {
+		t.Fatalf("Failed to create codec: %v", err)
+	}
+	message, err := layers.Encode(data)
+	if err != nil {
+		t.Fatalf("Failed to unwrap stored value: %v", err)
+	}
+	direct, err := newTestProtoSchemaCodec(t, "events.UserEvent").Encode(message)
+	if err != nil {
+		t.Fatalf("Failed to encode message: %v", err)
+	}
+	if !jsonEqual(t, direct, []byte(input)) {
+		t.Errorf("Expected %s, got %s", input, direct)
+	}
+
+	output, err := codec.Encode(data)
+	if err != nil {
+		t.Fatalf("Failed to encode data: %v", err)
+	}
+	if !jsonEqual(t, output, []byte(input)) {
+		t.Errorf("Expected %s, got %s", input, output)
+	}
+}
+
+func TestPipelineErrors(t *testing.T) {
+	for _, format := range []string{"base64,", "base64,unknown,json", ",json"} {
+		if _, err := NewCodec(format); err == nil {
+			t.Errorf("Expected error for format '%s', got nil", format)
+		}
+	}
+
+	codec, err := NewCodec("base64,gzip,raw")
+	if err != nil {
+		t.Fatalf("Failed to create codec: %v", err)
+	}
+	if EncodesJSON(codec) {
+		t.Errorf("Expected pipeline ending in raw not to encode JSON")
+	}
+	// Valid base64 that is not gzip data
+	if _, err := codec.Encode([]byte("aGVsbG8=")); err == nil {
+		t.Errorf("Expected error for uncompressed layer, got nil")
+	}
+	if _, err := codec.Encode([]byte("not base64!")); err == nil {
+		t.Errorf("Expected error for invalid base64 layer, got nil")
+	}
+}
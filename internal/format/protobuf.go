@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io"
+	"path/filepath"
 	"sort"
 	"strings"
 
@@ -14,9 +15,9 @@ import (
 // ProtobufCodec handles protobuf binary format
 type ProtobufCodec struct{}
 
-// Decode is not implemented for protobuf codec (as producer)
+// Decode is not supported without a schema, see ProtoSchemaCodec
 func (c *ProtobufCodec) Decode(input []byte) ([]byte, error) {
-	return nil, fmt.Errorf("protobuf encoding not implemented: use in consumer mode only")
+	return nil, fmt.Errorf("protobuf encoding requires a schema: use -I and -M to encode JSON input")
 }
 
 // Encode transforms protobuf wire format to human-readable text
@@ -194,9 +195,64 @@ func isPrintableASCII(data []byte) bool {
 	return true
 }
 
-// Register the protobuf codec
+// Register the protobuf codecs
 func init() {
-	registerCodec("protobuf", func() Codec {
-		return &ProtobufCodec{}
+	registerCodecWithOptions("protobuf", []string{"type", "import", "descriptor_set", "emit_defaults"}, func(opts codecOptions) (Codec, error) {
+		schema, err := protoSchema(opts)
+		if err != nil {
+			return nil, err
+		}
+
+		// Values in the Schema Registry wire format carry their own schema ID
+		if schema.RegistryURL != "" {
+			return NewRegistryProtoCodec(schema)
+		}
+		if schema.HasSchema() {
+			return NewProtoSchemaCodec(schema)
+		}
+		return &ProtobufCodec{}, nil
+	})
+
+	registerCodecWithOptions("prototext", []string{"type", "import", "descriptor_set"}, func(opts codecOptions) (Codec, error) {
+		schema, err := protoSchema(opts)
+		if err != nil {
+			return nil, err
+		}
+
+		if schema.RegistryURL != "" {
+			codec, err := NewRegistryProtoCodec(schema)
+			if err != nil {
+				return nil, err
+			}
+			codec.textFormat = true
+			return codec, nil
+		}
+		// Keys and headers have no schema flags, and fail only when used
+		if opts.schema == nil && !schema.HasSchema() {
+			return &ProtoSchemaCodec{textFormat: true}, nil
+		}
+		// The text format always requires a schema
+		return NewProtoTextCodec(schema)
 	})
 }
+
+// protoSchema returns the schema flags overridden by the protobuf options
+// given in the format
+func protoSchema(opts codecOptions) (SchemaOptions, error) {
+	schema := opts.baseSchema()
+	schema.MessageType = opts.string("type", schema.MessageType)
+	// Lists are separated like PATH, since commas separate options
+	if dirs := opts.string("import", ""); dirs != "" {
+		schema.ImportDirs = filepath.SplitList(dirs)
+	}
+	if files := opts.string("descriptor_set", ""); files != "" {
+		schema.DescriptorSets = filepath.SplitList(files)
+	}
+
+	emitDefaults, err := opts.bool("emit_defaults")
+	if err != nil {
+		return SchemaOptions{}, err
+	}
+	schema.EmitDefaults = schema.EmitDefaults || emitDefaults
+	return schema, nil
+}
@@ -9,38 +9,64 @@ import (
 
 	"github.com/bufbuild/protocompile"
 	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/prototext"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
 	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
 	"google.golang.org/protobuf/types/dynamicpb"
 )
 
+// SchemaOptions selects the schema used by schema-based codecs
+type SchemaOptions struct {
+	ImportDirs     []string // Directories to search for .proto files (-I flag)
+	DescriptorSets []string // Compiled FileDescriptorSet files (-descriptor-set flag)
+	MessageType    string   // Message type name (-M flag)
+	RegistryURL    string   // Schema Registry to look up schemas in (-r flag)
+	AvroSchemaFile string   // Avro schema file (-avsc flag)
+	JSONSchemaFile string   // JSON Schema file validating json values (-json-schema flag)
+	EmitDefaults   bool     // Include fields with default values in protobuf JSON
+	Subject        string   // Registry subject whose latest schema is used to produce values
+}
+
+// HasSchema reports whether a message type and a source to load it from are set
+func (o SchemaOptions) HasSchema() bool {
+	return o.MessageType != "" && (len(o.ImportDirs) > 0 || len(o.DescriptorSets) > 0)
+}
+
 // ProtoSchemaCodec handles protobuf decoding with schema files
 type ProtoSchemaCodec struct {
-	messageType protoreflect.MessageType
-	importDirs  []string
-	messageName string
+	messageType    protoreflect.MessageType
+	importDirs     []string
+	descriptorSets []string
+	messageName    string
+	textFormat     bool // Use the protobuf text format instead of JSON
+	emitDefaults   bool // Include fields with default values in JSON
 }
 
 // NewProtoSchemaCodec creates a new schema-based protobuf codec
-func NewProtoSchemaCodec(importDirs []string, messageTypeName string) (*ProtoSchemaCodec, error) {
-	if messageTypeName == "" {
+func NewProtoSchemaCodec(opts SchemaOptions) (*ProtoSchemaCodec, error) {
+	if opts.MessageType == "" {
 		return nil, fmt.Errorf("message type name (-M) is required for schema-based protobuf decoding")
 	}
 
-	if len(importDirs) == 0 {
-		return nil, fmt.Errorf("at least one import directory (-I) is required for schema-based protobuf decoding")
+	if len(opts.ImportDirs) == 0 && len(opts.DescriptorSets) == 0 {
+		return nil, fmt.Errorf("at least one import directory (-I) or descriptor set (-descriptor-set) is required for schema-based protobuf decoding")
 	}
 
 	// Validate import directories exist
-	for _, dir := range importDirs {
+	for _, dir := range opts.ImportDirs {
 		if _, err := os.Stat(dir); os.IsNotExist(err) {
 			return nil, fmt.Errorf("import directory does not exist: %s", dir)
 		}
 	}
 
 	codec := &ProtoSchemaCodec{
-		importDirs:  importDirs,
-		messageName: messageTypeName,
+		importDirs:     opts.ImportDirs,
+		descriptorSets: opts.DescriptorSets,
+		messageName:    opts.MessageType,
+		emitDefaults:   opts.EmitDefaults,
 	}
 
 	// Load the schema and resolve the message type
@@ -51,16 +77,68 @@ func NewProtoSchemaCodec(importDirs []string, messageTypeName string) (*ProtoSch
 	return codec, nil
 }
 
-// loadSchema compiles the proto files and resolves the message type
+// NewProtoTextCodec creates a schema-based protobuf codec using the protobuf
+// text format instead of JSON
+func NewProtoTextCodec(opts SchemaOptions) (*ProtoSchemaCodec, error) {
+	codec, err := NewProtoSchemaCodec(opts)
+	if err != nil {
+		return nil, err
+	}
+	codec.textFormat = true
+	return codec, nil
+}
+
+// loadSchema loads the descriptor sets and compiles the proto files, and
+// resolves the message type
 func (c *ProtoSchemaCodec) loadSchema() error {
+	var fileDescriptors []protoreflect.FileDescriptor
+
+	// Prebuilt descriptor sets are searched before compiled sources
+	for _, path := range c.descriptorSets {
+		files, err := loadDescriptorSet(path)
+		if err != nil {
+			return err
+		}
+		fileDescriptors = append(fileDescriptors, files...)
+	}
+
+	if len(c.importDirs) > 0 {
+		files, err := c.compileProtoFiles()
+		if err != nil {
+			return err
+		}
+		fileDescriptors = append(fileDescriptors, files...)
+	}
+
+	// Find the message type
+	var messageDesc protoreflect.MessageDescriptor
+	for _, fileDesc := range fileDescriptors {
+		messageDesc = findMessageInFile(fileDesc, c.messageName)
+		if messageDesc != nil {
+			break
+		}
+	}
+
+	if messageDesc == nil {
+		return fmt.Errorf("message type '%s' not found in proto files", c.messageName)
+	}
+
+	// Create the message type
+	c.messageType = dynamicpb.NewMessageType(messageDesc)
+
+	return nil
+}
+
+// compileProtoFiles compiles the proto files found in the import directories
+func (c *ProtoSchemaCodec) compileProtoFiles() ([]protoreflect.FileDescriptor, error) {
 	// Find all .proto files in the import directories
 	protoFiles, err := c.findProtoFiles()
 	if err != nil {
-		return fmt.Errorf("failed to find proto files: %w", err)
+		return nil, fmt.Errorf("failed to find proto files: %w", err)
 	}
 
 	if len(protoFiles) == 0 {
-		return fmt.Errorf("no .proto files found in import directories: %v", c.importDirs)
+		return nil, fmt.Errorf("no .proto files found in import directories: %v", c.importDirs)
 	}
 
 	// Create a compiler with the import directories
@@ -91,27 +169,55 @@ func (c *ProtoSchemaCodec) loadSchema() error {
 		}
 
 		if len(fileDescriptors) == 0 {
-			return fmt.Errorf("failed to compile proto files (no compilable files found). First error: %w", err)
+			return nil, fmt.Errorf("failed to compile proto files (no compilable files found). First error: %w", err)
 		}
 	}
 
-	// Find the message type
-	var messageDesc protoreflect.MessageDescriptor
-	for _, fileDesc := range fileDescriptors {
-		messageDesc = c.findMessageInFile(fileDesc, c.messageName)
-		if messageDesc != nil {
-			break
+	return fileDescriptors, nil
+}
+
+// loadDescriptorSet reads a FileDescriptorSet, as written by
+// protoc --descriptor_set_out or buf build. Imports of well-known types
+// missing from the set are resolved from the types built into kafkadog.
+func loadDescriptorSet(path string) ([]protoreflect.FileDescriptor, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read descriptor set: %w", err)
+	}
+
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse descriptor set %s: %w", path, err)
+	}
+
+	included := make(map[string]bool, len(set.File))
+	for _, file := range set.File {
+		included[file.GetName()] = true
+	}
+	for _, file := range set.File {
+		for _, dep := range file.GetDependency() {
+			if included[dep] {
+				continue
+			}
+			if fd, err := protoregistry.GlobalFiles.FindFileByPath(dep); err == nil {
+				set.File = append(set.File, protodesc.ToFileDescriptorProto(fd))
+				included[dep] = true
+			}
 		}
 	}
 
-	if messageDesc == nil {
-		return fmt.Errorf("message type '%s' not found in proto files", c.messageName)
+	files, err := protodesc.NewFiles(&set)
+	if err != nil {
+		return nil, fmt.Errorf("invalid descriptor set %s (build it with imports included, e.g. protoc --include_imports): %w", path, err)
 	}
 
-	// Create the message type
-	c.messageType = dynamicpb.NewMessageType(messageDesc)
+	var fileDescriptors []protoreflect.FileDescriptor
+	files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		fileDescriptors = append(fileDescriptors, fd)
+		return true
+	})
 
-	return nil
+	return fileDescriptors, nil
 }
 
 // findProtoFiles recursively finds all .proto files in the import directories
@@ -142,7 +248,7 @@ func (c *ProtoSchemaCodec) findProtoFiles() ([]string, error) {
 }
 
 // findMessageInFile searches for a message type in a file descriptor
-func (c *ProtoSchemaCodec) findMessageInFile(fileDesc protoreflect.FileDescriptor, messageName string) protoreflect.MessageDescriptor {
+func findMessageInFile(fileDesc protoreflect.FileDescriptor, messageName string) protoreflect.MessageDescriptor {
 	// Try direct lookup first
 	messageDesc := fileDesc.Messages().ByName(protoreflect.Name(messageName))
 	if messageDesc != nil {
@@ -167,7 +273,7 @@ func (c *ProtoSchemaCodec) findMessageInFile(fileDesc protoreflect.FileDescripto
 	messages := fileDesc.Messages()
 	for i := 0; i < messages.Len(); i++ {
 		msg := messages.Get(i)
-		if found := c.findNestedMessage(msg, messageName); found != nil {
+		if found := findNestedMessage(msg, messageName); found != nil {
 			return found
 		}
 	}
@@ -176,7 +282,7 @@ func (c *ProtoSchemaCodec) findMessageInFile(fileDesc protoreflect.FileDescripto
 }
 
 // findNestedMessage recursively searches for a message in nested messages
-func (c *ProtoSchemaCodec) findNestedMessage(messageDesc protoreflect.MessageDescriptor, messageName string) protoreflect.MessageDescriptor {
+func findNestedMessage(messageDesc protoreflect.MessageDescriptor, messageName string) protoreflect.MessageDescriptor {
 	// Check if this message matches
 	if string(messageDesc.Name()) == messageName || string(messageDesc.FullName()) == messageName {
 		return messageDesc
@@ -186,7 +292,7 @@ func (c *ProtoSchemaCodec) findNestedMessage(messageDesc protoreflect.MessageDes
 	nested := messageDesc.Messages()
 	for i := 0; i < nested.Len(); i++ {
 		nestedMsg := nested.Get(i)
-		if found := c.findNestedMessage(nestedMsg, messageName); found != nil {
+		if found := findNestedMessage(nestedMsg, messageName); found != nil {
 			return found
 		}
 	}
@@ -194,31 +300,61 @@ func (c *ProtoSchemaCodec) findNestedMessage(messageDesc protoreflect.MessageDes
 	return nil
 }
 
-// Decode is not implemented for schema-based protobuf codec (producer mode not supported)
+// Decode transforms JSON or text format to protobuf wire format using the schema
 func (c *ProtoSchemaCodec) Decode(input []byte) ([]byte, error) {
-	return nil, fmt.Errorf("schema-based protobuf encoding not implemented: use in consumer mode only")
+	if c.messageType == nil {
+		return nil, fmt.Errorf("message type not loaded: schema-based formats require -I and -M")
+	}
+
+	return unmarshalMessage(c.messageType, input, c.textFormat)
+}
+
+// EncodesJSON reports whether the codec renders messages as JSON rather than
+// text format
+func (c *ProtoSchemaCodec) EncodesJSON() bool {
+	return !c.textFormat
 }
 
-// Encode transforms protobuf wire format to JSON using the schema
+// Encode transforms protobuf wire format to JSON or text format using the schema
 func (c *ProtoSchemaCodec) Encode(input []byte) ([]byte, error) {
 	if c.messageType == nil {
-		return nil, fmt.Errorf("message type not loaded")
+		return nil, fmt.Errorf("message type not loaded: schema-based formats require -I and -M")
 	}
 
+	return marshalMessage(c.messageType, input, c.textFormat, c.emitDefaults)
+}
+
+// marshalMessage renders protobuf wire format data of the message type as
+// JSON or text format
+func marshalMessage(messageType protoreflect.MessageType, input []byte, textFormat, emitDefaults bool) ([]byte, error) {
 	// Create a new message instance
-	message := c.messageType.New()
+	message := messageType.New()
 
 	// Unmarshal the protobuf data
 	if err := proto.Unmarshal(input, message.Interface()); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal protobuf data: %w", err)
 	}
 
+	if textFormat {
+		marshaler := prototext.MarshalOptions{
+			Multiline: true,
+			Indent:    "  ",
+		}
+
+		textData, err := marshaler.Marshal(message.Interface())
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal to text format: %w", err)
+		}
+
+		return textData, nil
+	}
+
 	// Convert to JSON
 	marshaler := protojson.MarshalOptions{
 		Multiline:       true,
 		Indent:          "  ",
 		UseProtoNames:   false,
-		EmitUnpopulated: false,
+		EmitUnpopulated: emitDefaults,
 	}
 
 	jsonData, err := marshaler.Marshal(message.Interface())
@@ -228,3 +364,24 @@ func (c *ProtoSchemaCodec) Encode(input []byte) ([]byte, error) {
 
 	return jsonData, nil
 }
+
+// unmarshalMessage parses JSON or text format input as the message type and
+// returns it in protobuf wire format
+func unmarshalMessage(messageType protoreflect.MessageType, input []byte, textFormat bool) ([]byte, error) {
+	// Parse the input into a new message instance
+	message := messageType.New()
+	if textFormat {
+		if err := prototext.Unmarshal(input, message.Interface()); err != nil {
+			return nil, fmt.Errorf("failed to parse text format as %s: %w", messageType.Descriptor().FullName(), err)
+		}
+	} else if err := protojson.Unmarshal(input, message.Interface()); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON as %s: %w", messageType.Descriptor().FullName(), err)
+	}
+
+	data, err := proto.Marshal(message.Interface())
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal protobuf data: %w", err)
+	}
+
+	return data, nil
+}
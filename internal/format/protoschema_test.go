@@ -0,0 +1,376 @@
+package format
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/bufbuild/protocompile"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// testProto is a schema exercising nested messages, enums and repeated fields
+const testProto = `syntax = "proto3";
+
+package events;
+
+message UserEvent {
+  enum Kind {
+    KIND_UNSPECIFIED = 0;
+    KIND_SIGNUP = 1;
+    KIND_LOGIN = 2;
+  }
+
+  message User {
+    string id = 1;
+    string email = 2;
+  }
+
+  User user = 1;
+  Kind kind = 2;
+  repeated string tags = 3;
+  int64 timestamp = 4;
+}
+`
+
+// newTestProtoSchemaCodec writes the test schema to a temporary import
+// directory and loads the given message type from it
+func newTestProtoSchemaCodec(t *testing.T, messageType string) *ProtoSchemaCodec {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "events.proto"), []byte(testProto), 0o600); err != nil {
+		t.Fatalf("Failed to write proto file: %v", err)
+	}
+
+	codec, err := NewProtoSchemaCodec(SchemaOptions{ImportDirs: []string{dir}, MessageType: messageType})
+	if err != nil {
+		t.Fatalf("Failed to create codec: %v", err)
+	}
+	return codec
+}
+
+// jsonEqual reports whether two JSON documents hold the same value
+func jsonEqual(t *testing.T, a, b []byte) bool {
+	t.Helper()
+	var va, vb any
+	if err := json.Unmarshal(a, &va); err != nil {
+		t.Fatalf("Invalid JSON %q: %v", a, err)
+	}
+	if err := json.Unmarshal(b, &vb); err != nil {
+		t.Fatalf("Invalid JSON %q: %v", b, err)
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+func TestProtoSchemaCodecDecode(t *testing.T) {
+	codec := newTestProtoSchemaCodec(t, "events.UserEvent")
+
+	tests := []struct {
+		name        string
+		input       string
+		expected    string // JSON rendering of the encoded message
+		expectError bool
+	}{
+		{
+			name:     "nested message, enum and repeated field",
+			input:    `{"user":{"id":"u1","email":"u1@example.com"},"kind":"KIND_SIGNUP","tags":["a","b"],"timestamp":"1700000000000"}`,
+			expected: `{"user":{"id":"u1","email":"u1@example.com"},"kind":"KIND_SIGNUP","tags":["a","b"],"timestamp":"1700000000000"}`,
+		},
+		{
+			name:     "proto field names and numeric enum",
+			input:    `{"user":{"id":"u2"},"kind":2,"timestamp":42}`,
+			expected: `{"user":{"id":"u2"},"kind":"KIND_LOGIN","timestamp":"42"}`,
+		},
+		{
+			name:     "empty message",
+			input:    `{}`,
+			expected: `{}`,
+		},
+		{
+			name:        "unknown field",
+			input:       `{"usr":{"id":"u1"}}`,
+			expectError: true,
+		},
+		{
+			name:        "wrong field type",
+			input:       `{"tags":"a"}`,
+			expectError: true,
+		},
+		{
+			name:        "invalid JSON",
+			input:       `{"user":`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := codec.Decode([]byte(tt.input))
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			output, err := codec.Encode(data)
+			if err != nil {
+				t.Fatalf("Failed to encode produced data: %v", err)
+			}
+			if !jsonEqual(t, output, []byte(tt.expected)) {
+				t.Errorf("Expected %s, got %s", tt.expected, output)
+			}
+		})
+	}
+}
+
+func TestProtoSchemaCodecNestedType(t *testing.T) {
+	codec := newTestProtoSchemaCodec(t, "User")
+
+	data, err := codec.Decode([]byte(`{"id":"u1","email":"u1@example.com"}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	output, err := codec.Encode(data)
+	if err != nil {
+		t.Fatalf("Failed to encode produced data: %v", err)
+	}
+	if !jsonEqual(t, output, []byte(`{"id":"u1","email":"u1@example.com"}`)) {
+		t.Errorf("Expected the nested User message, got %s", output)
+	}
+}
+
+func TestProtoTextCodec(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "events.proto"), []byte(testProto), 0o600); err != nil {
+		t.Fatalf("Failed to write proto file: %v", err)
+	}
+
+	textCodec, err := NewCodecWithSchema("prototext", SchemaOptions{ImportDirs: []string{dir}, MessageType: "events.UserEvent"})
+	if err != nil {
+		t.Fatalf("Failed to create text codec: %v", err)
+	}
+	if EncodesJSON(textCodec) {
+		t.Errorf("Expected text codec not to encode JSON")
+	}
+	jsonCodec := newTestProtoSchemaCodec(t, "events.UserEvent")
+
+	tests := []struct {
+		name        string
+		input       string
+		expected    string // JSON rendering of the encoded message
+		expectError bool
+	}{
+		{
+			name:     "single line",
+			input:    `user: {id: "u1" email: "u1@example.com"} kind: KIND_SIGNUP tags: "a" tags: "b"`,
+			expected: `{"user":{"id":"u1","email":"u1@example.com"},"kind":"KIND_SIGNUP","tags":["a","b"]}`,
+		},
+		{
+			name:     "multiline with list syntax",
+			input:    "user {\n  id: \"u2\"\n}\ntags: [\"x\", \"y\"]\ntimestamp: 42\n",
+			expected: `{"user":{"id":"u2"},"tags":["x","y"],"timestamp":"42"}`,
+		},
+		{
+			name:        "unknown field",
+			input:       `usr: {id: "u1"}`,
+			expectError: true,
+		},
+		{
+			name:        "JSON input",
+			input:       `{"user":{"id":"u1"}}`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := textCodec.Decode([]byte(tt.input))
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			output, err := jsonCodec.Encode(data)
+			if err != nil {
+				t.Fatalf("Failed to encode produced data as JSON: %v", err)
+			}
+			if !jsonEqual(t, output, []byte(tt.expected)) {
+				t.Errorf("Expected %s, got %s", tt.expected, output)
+			}
+
+			// Text output parses back into the same message
+			text, err := textCodec.Encode(data)
+			if err != nil {
+				t.Fatalf("Failed to encode as text format: %v", err)
+			}
+			roundTrip, err := textCodec.Decode(text)
+			if err != nil {
+				t.Fatalf("Failed to parse text output %q: %v", text, err)
+			}
+			output, err = jsonCodec.Encode(roundTrip)
+			if err != nil {
+				t.Fatalf("Failed to encode round-tripped data as JSON: %v", err)
+			}
+			if !jsonEqual(t, output, []byte(tt.expected)) {
+				t.Errorf("Expected %s after round trip, got %s", tt.expected, output)
+			}
+		})
+	}
+}
+
+func TestProtoTextCodecRequiresSchema(t *testing.T) {
+	if _, err := NewCodecWithSchema("prototext", SchemaOptions{}); err == nil {
+		t.Errorf("Expected error creating prototext codec without a schema, got nil")
+	}
+
+	// Without a schema the registered codec fails on use
+	codec, err := NewCodec("prototext")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := codec.Encode([]byte{0x0a, 0x00}); err == nil {
+		t.Errorf("Expected error encoding without a schema, got nil")
+	}
+}
+
+// writeDescriptorSet compiles proto sources and writes the named files as a
+// FileDescriptorSet, leaving out their imports
+func writeDescriptorSet(t *testing.T, sources map[string]string, names ...string) string {
+	t.Helper()
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			Accessor: protocompile.SourceAccessorFromMap(sources),
+		}),
+	}
+	files, err := compiler.Compile(context.Background(), names...)
+	if err != nil {
+		t.Fatalf("Failed to compile proto files: %v", err)
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, file := range files {
+		set.File = append(set.File, protodesc.ToFileDescriptorProto(file))
+	}
+	data, err := proto.Marshal(set)
+	if err != nil {
+		t.Fatalf("Failed to marshal descriptor set: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "schema.pb")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("Failed to write descriptor set: %v", err)
+	}
+	return path
+}
+
+func TestProtoSchemaCodecDescriptorSet(t *testing.T) {
+	sources := map[string]string{
+		"events.proto": testProto,
+		"audit.proto": `syntax = "proto3";
+
+package audit;
+
+import "events.proto";
+import "google/protobuf/timestamp.proto";
+
+message AuditRecord {
+  events.UserEvent event = 1;
+  google.protobuf.Timestamp recorded_at = 2;
+}
+`,
+	}
+	withImports := writeDescriptorSet(t, sources, "events.proto", "audit.proto")
+	withoutImports := writeDescriptorSet(t, sources, "audit.proto")
+
+	garbage := filepath.Join(t.TempDir(), "garbage.pb")
+	if err := os.WriteFile(garbage, []byte("not a descriptor set"), 0o600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		sets        []string
+		messageType string
+		input       string
+		expectError bool
+	}{
+		{
+			name:        "message with well-known type import",
+			sets:        []string{withImports},
+			messageType: "audit.AuditRecord",
+			input:       `{"event":{"user":{"id":"u1"},"kind":"KIND_LOGIN"},"recordedAt":"2024-01-02T03:04:05Z"}`,
+		},
+		{
+			name:        "message from dependency",
+			sets:        []string{withImports},
+			messageType: "events.UserEvent",
+			input:       `{"user":{"id":"u1"}}`,
+		},
+		{
+			name:        "message not found",
+			sets:        []string{withImports},
+			messageType: "audit.Missing",
+			expectError: true,
+		},
+		{
+			name:        "missing import",
+			sets:        []string{withoutImports},
+			messageType: "audit.AuditRecord",
+			expectError: true,
+		},
+		{
+			name:        "invalid descriptor set",
+			sets:        []string{garbage},
+			messageType: "audit.AuditRecord",
+			expectError: true,
+		},
+		{
+			name:        "missing descriptor set",
+			sets:        []string{filepath.Join(t.TempDir(), "missing.pb")},
+			messageType: "audit.AuditRecord",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codec, err := NewCodecWithSchema("protobuf", SchemaOptions{DescriptorSets: tt.sets, MessageType: tt.messageType})
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to create codec: %v", err)
+			}
+
+			data, err := codec.Decode([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("Failed to decode JSON: %v", err)
+			}
+			output, err := codec.Encode(data)
+			if err != nil {
+				t.Fatalf("Failed to encode data: %v", err)
+			}
+			if !jsonEqual(t, output, []byte(tt.input)) {
+				t.Errorf("Expected %s, got %s", tt.input, output)
+			}
+		})
+	}
+}
@@ -1,11 +1,57 @@
 package format
 
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// codecFactory creates a codec from the options given in its format
+type codecFactory func(opts codecOptions) (Codec, error)
+
+// codecEntry is a registered codec with the names of the options it accepts
+type codecEntry struct {
+	factory    codecFactory
+	options    []string
+	rawOptions bool // Options are passed as text, e.g. a command line
+	layer      bool // Only unwraps values, followed by raw when last in a format
+}
+
 // Map of registered codecs
-var codecRegistry = make(map[string]func() Codec)
+var codecRegistry = make(map[string]codecEntry)
 
-// registerCodec registers a codec factory function
+// registerCodec registers a codec factory function for a codec without options
 func registerCodec(name string, factory func() Codec) {
-	codecRegistry[name] = factory
+	codecRegistry[name] = codecEntry{
+		factory: func(codecOptions) (Codec, error) {
+			return factory(), nil
+		},
+	}
+}
+
+// registerLayerCodec registers a codec factory function for a codec that only
+// wraps values, such as compression. Formats ending in it render the unwrapped
+// value as raw bytes.
+func registerLayerCodec(name string, factory func() Codec) {
+	codecRegistry[name] = codecEntry{
+		factory: func(codecOptions) (Codec, error) {
+			return factory(), nil
+		},
+		layer: true,
+	}
+}
+
+// registerCodecWithOptions registers a codec factory function for a codec
+// accepting the named options
+func registerCodecWithOptions(name string, options []string, factory codecFactory) {
+	codecRegistry[name] = codecEntry{factory: factory, options: options}
+}
+
+// registerCodecWithRawOptions registers a codec factory function for a codec
+// taking its options as text rather than as key=value pairs
+func registerCodecWithRawOptions(name string, factory codecFactory) {
+	codecRegistry[name] = codecEntry{factory: factory, rawOptions: true}
 }
 
 // GetAvailableFormats returns a list of all registered codec formats
@@ -16,3 +62,170 @@ func GetAvailableFormats() []string {
 	}
 	return formats
 }
+
+// codecOptions holds the options of a pipeline stage, given after a colon as
+// comma-separated key=value pairs or flags, e.g. "hex:upper,group=2"
+type codecOptions struct {
+	format string            // Name of the codec
+	values map[string]string // Options by name; flags have the value "true"
+	raw    string            // Option text, for codecs registered with raw options
+	schema *SchemaOptions    // Schema flags, nil for codecs they don't apply to
+}
+
+// string returns the value of the option, or def when it is not given
+func (o codecOptions) string(name, def string) string {
+	if value, ok := o.values[name]; ok {
+		return value
+	}
+	return def
+}
+
+// bool returns the value of a flag option
+func (o codecOptions) bool(name string) (bool, error) {
+	value, ok := o.values[name]
+	if !ok {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid value for option %s of format %s: %s", name, o.format, value)
+	}
+	return b, nil
+}
+
+// int returns the value of an integer option, or def when it is not given
+func (o codecOptions) int(name string, def int) (int, error) {
+	value, ok := o.values[name]
+	if !ok {
+		return def, nil
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value for option %s of format %s: %s", name, o.format, value)
+	}
+	return i, nil
+}
+
+// baseSchema returns the schema flags, or empty options when they don't apply
+func (o codecOptions) baseSchema() SchemaOptions {
+	if o.schema == nil {
+		return SchemaOptions{}
+	}
+	return *o.schema
+}
+
+// formatStage is a parsed stage of a format
+type formatStage struct {
+	name   string
+	values map[string]string
+	raw    string // Option text, for codecs registered with raw options
+}
+
+// parseFormat parses a format into its pipeline stages and their options,
+// checking that the codecs exist and accept the options. Stages and options
+// are both separated by commas: an entry naming a registered codec starts a
+// new stage, other entries are options of the stage before them.
+func parseFormat(format string) ([]formatStage, error) {
+	var stages []formatStage
+	hasOptions := false // Whether the current stage was given options
+	for _, part := range strings.Split(format, PipelineSeparator) {
+		entry := strings.TrimSpace(part)
+
+		name, options, found := strings.Cut(entry, ":")
+		if _, ok := codecRegistry[name]; ok || len(stages) == 0 || !hasOptions {
+			stages = append(stages, formatStage{name: name, values: make(map[string]string)})
+			hasOptions = found
+		} else if codecRegistry[stages[len(stages)-1].name].rawOptions {
+			// Text containing commas, kept with its spacing
+			options = PipelineSeparator + part
+		} else {
+			// An option following the options of the current stage
+			options = entry
+		}
+		if options == "" {
+			continue
+		}
+
+		current := &stages[len(stages)-1]
+		if codecRegistry[current.name].rawOptions {
+			current.raw += options
+			continue
+		}
+
+		key, value, found := strings.Cut(options, "=")
+		if !found {
+			value = "true"
+		}
+		current.values[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+
+	for _, stage := range stages {
+		entry, ok := codecRegistry[stage.name]
+		if !ok {
+			return nil, fmt.Errorf("unsupported format: %s", stage.name)
+		}
+		for key := range stage.values {
+			if !slices.Contains(entry.options, key) {
+				if len(entry.options) == 0 {
+					return nil, fmt.Errorf("format %s takes no options, got '%s'", stage.name, key)
+				}
+				return nil, fmt.Errorf("unknown option '%s' for format %s. Must be one of: %s", key, stage.name, strings.Join(entry.options, ", "))
+			}
+		}
+	}
+
+	return stages, nil
+}
+
+// ReplaceSeparator converts a format whose stages and options are separated by
+// separator instead of commas. Like commas, the separator is kept in the
+// option text of codecs such as exec, up to an entry naming another codec.
+func ReplaceSeparator(format, separator string) string {
+	var b strings.Builder
+	rawOptions := false // Whether the current stage takes its options as text
+	for i, part := range strings.Split(format, separator) {
+		name, _, found := strings.Cut(strings.TrimSpace(part), ":")
+		entry, ok := codecRegistry[name]
+		if i > 0 {
+			if rawOptions && !ok {
+				b.WriteString(separator)
+			} else {
+				b.WriteString(PipelineSeparator)
+			}
+		}
+		if ok {
+			rawOptions = entry.rawOptions && found
+		}
+		b.WriteString(part)
+	}
+	return b.String()
+}
+
+// ValidateFormat checks that a format names registered codecs and only gives
+// them options they accept, without creating the codecs
+func ValidateFormat(format string) error {
+	_, err := parseFormat(format)
+	return err
+}
+
+// ValidateProduceFormat checks that values can be produced in a format, which
+// protobuf values can only be with a schema to encode them with
+func ValidateProduceFormat(format string, schema SchemaOptions) error {
+	stages, err := parseFormat(format)
+	if err != nil {
+		return err
+	}
+
+	last := stages[len(stages)-1]
+	if last.name != "protobuf" {
+		return nil
+	}
+	opts, err := protoSchema(codecOptions{format: last.name, values: last.values, schema: &schema})
+	if err != nil {
+		return err
+	}
+	if opts.RegistryURL == "" && !opts.HasSchema() {
+		return fmt.Errorf("protobuf values require a message type (-M) with proto files (-I) or descriptor sets (-descriptor-set), or a Schema Registry (-r)")
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+package format
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		name        string
+		format      string
+		expected    []formatStage
+		expectError bool
+	}{
+		{
+			name:     "single format",
+			format:   "hex",
+			expected: []formatStage{{name: "hex", values: map[string]string{}}},
+		},
+		{
+			name:   "options and flags",
+			format: "hex:upper,group=2",
+			expected: []formatStage{
+				{name: "hex", values: map[string]string{"upper": "true", "group": "2"}},
+			},
+		},
+		{
+			name:   "pipeline with options",
+			format: "base64:url, gzip, protobuf:type=pkg.Msg,emit_defaults=false",
+			expected: []formatStage{
+				{name: "base64", values: map[string]string{"url": "true"}},
+				{name: "gzip", values: map[string]string{}},
+				{name: "protobuf", values: map[string]string{"type": "pkg.Msg", "emit_defaults": "false"}},
+			},
+		},
+		{
+			name:   "option values with colons",
+			format: "json:schema=C:/schemas/event.json",
+			expected: []formatStage{
+				{name: "json", values: map[string]string{"schema": "C:/schemas/event.json"}},
+			},
+		},
+		{
+			name:   "text options",
+			format: "exec:./decoder --fields=a, b,json",
+			expected: []formatStage{
+				{name: "exec", values: map[string]string{}, raw: "./decoder --fields=a, b"},
+				{name: "json", values: map[string]string{}},
+			},
+		},
+		{
+			name:        "unknown format",
+			format:      "hex,upper",
+			expectError: true,
+		},
+		{
+			name:        "unknown option",
+			format:      "hex:lower",
+			expectError: true,
+		},
+		{
+			name:        "options for a codec without options",
+			format:      "raw:upper",
+			expectError: true,
+		},
+		{
+			name:        "empty stage",
+			format:      "hex,,raw",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stages, err := parseFormat(tt.format)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error, got nil")
+				}
+				if ValidateFormat(tt.format) == nil {
+					t.Errorf("Expected ValidateFormat to fail")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to parse format: %v", err)
+			}
+			if !reflect.DeepEqual(stages, tt.expected) {
+				t.Errorf("Expected %+v, got %+v", tt.expected, stages)
+			}
+		})
+	}
+}
+
+func TestCodecOptions(t *testing.T) {
+	tests := []struct {
+		format   string
+		input    []byte
+		expected string
+	}{
+		{"hex", []byte{0xde, 0xad, 0xbe, 0xef, 0x01}, "deadbeef01"},
+		{"hex:upper", []byte{0xde, 0xad, 0xbe, 0xef, 0x01}, "DEADBEEF01"},
+		{"hex:group=2", []byte{0xde, 0xad, 0xbe, 0xef, 0x01}, "dead beef 01"},
+		{"hex:upper,group=1", []byte{0xde, 0xad}, "DE AD"},
+		{"base64", []byte{0xfb, 0xff}, "+/8="},
+		{"base64:url", []byte{0xfb, 0xff}, "-_8="},
+		{"base64:url,nopad", []byte{0xfb, 0xff}, "-_8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			codec, err := NewCodec(tt.format)
+			if err != nil {
+				t.Fatalf("Failed to create codec: %v", err)
+			}
+
+			output, err := codec.Encode(tt.input)
+			if err != nil {
+				t.Fatalf("Failed to encode: %v", err)
+			}
+			if string(output) != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, output)
+			}
+
+			data, err := codec.Decode(output)
+			if err != nil {
+				t.Fatalf("Failed to decode: %v", err)
+			}
+			if !reflect.DeepEqual(data, tt.input) {
+				t.Errorf("Expected %x, got %x", tt.input, data)
+			}
+		})
+	}
+}
+
+func TestCodecOptionErrors(t *testing.T) {
+	for _, format := range []string{"hex:group=two", "hex:upper=maybe", "base64:nopad=2"} {
+		if _, err := NewCodec(format); err == nil {
+			t.Errorf("Expected error for format '%s', got nil", format)
+		}
+	}
+}
+
+func TestReplaceSeparator(t *testing.T) {
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{"base64+gzip+json", "base64,gzip,json"},
+		{"base64:url+nopad+json", "base64:url,nopad,json"},
+		{"exec:foo +x", "exec:foo +x"},
+		{"base64+exec:foo a+b+json", "base64,exec:foo a+b,json"},
+		{"exec+raw", "exec,raw"},
+	}
+
+	for _, tt := range tests {
+		if format := ReplaceSeparator(tt.format, "+"); format != tt.expected {
+			t.Errorf("Expected '%s' for '%s', got '%s'", tt.expected, tt.format, format)
+		}
+	}
+}
+
+func TestProtobufCodecOptions(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "events.proto"), []byte(testProto), 0o600); err != nil {
+		t.Fatalf("Failed to write proto file: %v", err)
+	}
+	message := newTestProtoSchemaCodec(t, "events.UserEvent.User")
+	data, err := message.Decode([]byte(`{"id":"u1"}`))
+	if err != nil {
+		t.Fatalf("Failed to decode JSON: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		format   string
+		schema   SchemaOptions
+		expected string
+	}{
+		{
+			name:     "schema from options",
+			format:   "protobuf:type=events.UserEvent.User,import=" + dir,
+			expected: `{"id":"u1"}`,
+		},
+		{
+			name:     "options override schema flags",
+			format:   "protobuf:type=events.UserEvent.User",
+			schema:   SchemaOptions{ImportDirs: []string{dir}, MessageType: "events.UserEvent"},
+			expected: `{"id":"u1"}`,
+		},
+		{
+			name:     "multiple import directories",
+			format:   "protobuf:type=events.UserEvent.User,import=" + t.TempDir() + string(os.PathListSeparator) + dir,
+			expected: `{"id":"u1"}`,
+		},
+		{
+			name:     "emit defaults",
+			format:   "protobuf:type=events.UserEvent.User,import=" + dir + ",emit_defaults",
+			expected: `{"id":"u1","email":""}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codec, err := NewCodecWithSchema(tt.format, tt.schema)
+			if err != nil {
+				t.Fatalf("Failed to create codec: %v", err)
+			}
+			output, err := codec.Encode(data)
+			if err != nil {
+				t.Fatalf("Failed to encode data: %v", err)
+			}
+			if !jsonEqual(t, output, []byte(tt.expected)) {
+				t.Errorf("Expected %s, got %s", tt.expected, output)
+			}
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package format
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bufbuild/protocompile"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// RegistryProtoCodec handles protobuf values in the Confluent Schema Registry
+// wire format, looking up the schema of each value by its schema ID
+type RegistryProtoCodec struct {
+	registry     *SchemaRegistry
+	subject      string // Subject whose latest schema is used to produce values
+	messageName  string // Message type to produce, defaults to the first message in the schema
+	textFormat   bool   // Use the protobuf text format instead of JSON
+	emitDefaults bool   // Include fields with default values in JSON
+
+	files map[int]protoreflect.FileDescriptor // Compiled schemas by schema ID
+	types map[string]protoreflect.MessageType // Message types by schema ID and message indexes
+}
+
+// NewRegistryProtoCodec creates a protobuf codec using the Schema Registry
+func NewRegistryProtoCodec(opts SchemaOptions) (*RegistryProtoCodec, error) {
+	registry, err := NewSchemaRegistry(opts.RegistryURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RegistryProtoCodec{
+		registry:     registry,
+		subject:      opts.Subject,
+		messageName:  opts.MessageType,
+		emitDefaults: opts.EmitDefaults,
+		files:        make(map[int]protoreflect.FileDescriptor),
+		types:        make(map[string]protoreflect.MessageType),
+	}, nil
+}
+
+// EncodesJSON reports whether the codec renders messages as JSON rather than
+// text format
+func (c *RegistryProtoCodec) EncodesJSON() bool {
+	return !c.textFormat
+}
+
+// Encode transforms a value in the wire format to JSON or text format using
+// the schema registered under its schema ID
+func (c *RegistryProtoCodec) Encode(input []byte) ([]byte, error) {
+	id, payload, err := parseWireHeader(input)
+	if err != nil {
+		return nil, err
+	}
+
+	indexes, payload, err := readMessageIndexes(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	messageType, err := c.messageType(id, indexes)
+	if err != nil {
+		return nil, err
+	}
+
+	return marshalMessage(messageType, payload, c.textFormat, c.emitDefaults)
+}
+
+// Decode transforms JSON or text format to the wire format using the latest
+// schema registered under the subject
+func (c *RegistryProtoCodec) Decode(input []byte) ([]byte, error) {
+	if c.subject == "" {
+		return nil, fmt.Errorf("producing with the Schema Registry requires a subject")
+	}
+
+	schema, err := c.registry.LatestSchema(c.subject)
+	if err != nil {
+		return nil, err
+	}
+	file, err := c.schemaFile(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	var messageDesc protoreflect.MessageDescriptor
+	if c.messageName != "" {
+		messageDesc = findMessageInFile(file, c.messageName)
+		if messageDesc == nil {
+			return nil, fmt.Errorf("message type '%s' not found in schema %d of subject %s", c.messageName, schema.ID, c.subject)
+		}
+	} else if file.Messages().Len() > 0 {
+		messageDesc = file.Messages().Get(0)
+	} else {
+		return nil, fmt.Errorf("schema %d of subject %s has no message types", schema.ID, c.subject)
+	}
+
+	data, err := unmarshalMessage(dynamicpb.NewMessageType(messageDesc), input, c.textFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	output := appendWireHeader(nil, schema.ID)
+	output = appendMessageIndexes(output, messageIndexes(messageDesc))
+	return append(output, data...), nil
+}
+
+// messageType returns the message type at the message indexes of the schema
+// with the given ID
+func (c *RegistryProtoCodec) messageType(id int, indexes []int) (protoreflect.MessageType, error) {
+	key := fmt.Sprint(id, indexes)
+	if messageType, ok := c.types[key]; ok {
+		return messageType, nil
+	}
+
+	schema, err := c.registry.SchemaByID(id)
+	if err != nil {
+		return nil, err
+	}
+	file, err := c.schemaFile(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	// Each index selects a message within the file or the previous message
+	var messageDesc protoreflect.MessageDescriptor
+	messages := file.Messages()
+	for _, index := range indexes {
+		if index < 0 || index >= messages.Len() {
+			return nil, fmt.Errorf("message indexes %v not found in schema %d", indexes, id)
+		}
+		messageDesc = messages.Get(index)
+		messages = messageDesc.Messages()
+	}
+
+	messageType := dynamicpb.NewMessageType(messageDesc)
+	c.types[key] = messageType
+	return messageType, nil
+}
+
+// schemaFile compiles a registered protobuf schema along with its references
+func (c *RegistryProtoCodec) schemaFile(schema *RegistrySchema) (protoreflect.FileDescriptor, error) {
+	if file, ok := c.files[schema.ID]; ok {
+		return file, nil
+	}
+
+	if schema.schemaType() != SchemaTypeProtobuf {
+		return nil, fmt.Errorf("schema %d is a %s schema, not %s", schema.ID, schema.schemaType(), SchemaTypeProtobuf)
+	}
+
+	references, err := c.registry.References(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	sources := make(map[string]string, len(references)+1)
+	for name, reference := range references {
+		sources[name] = reference.Schema
+	}
+	name := fmt.Sprintf("schema-%d.proto", schema.ID)
+	sources[name] = schema.Schema
+
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			Accessor: protocompile.SourceAccessorFromMap(sources),
+		}),
+	}
+	files, err := compiler.Compile(context.Background(), name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile schema %d: %w", schema.ID, err)
+	}
+
+	c.files[schema.ID] = files[0]
+	return files[0], nil
+}
+
+// readMessageIndexes reads the zigzag-encoded message indexes that follow the
+// wire format header of protobuf values. A single 0 stands for the first
+// message in the schema.
+func readMessageIndexes(input []byte) ([]int, []byte, error) {
+	count, n := protowire.ConsumeVarint(input)
+	if n < 0 {
+		return nil, nil, fmt.Errorf("invalid message indexes: %w", protowire.ParseError(n))
+	}
+	input = input[n:]
+
+	length := protowire.DecodeZigZag(count)
+	if length == 0 {
+		return []int{0}, input, nil
+	}
+	if length < 0 || length > int64(len(input)) {
+		return nil, nil, fmt.Errorf("invalid message index count %d", length)
+	}
+
+	indexes := make([]int, length)
+	for i := range indexes {
+		index, n := protowire.ConsumeVarint(input)
+		if n < 0 {
+			return nil, nil, fmt.Errorf("invalid message indexes: %w", protowire.ParseError(n))
+		}
+		indexes[i] = int(protowire.DecodeZigZag(index))
+		input = input[n:]
+	}
+
+	return indexes, input, nil
+}
+
+// appendMessageIndexes appends message indexes in the wire format to buf
+func appendMessageIndexes(buf []byte, indexes []int) []byte {
+	if len(indexes) == 1 && indexes[0] == 0 {
+		return append(buf, 0)
+	}
+
+	buf = protowire.AppendVarint(buf, protowire.EncodeZigZag(int64(len(indexes))))
+	for _, index := range indexes {
+		buf = protowire.AppendVarint(buf, protowire.EncodeZigZag(int64(index)))
+	}
+	return buf
+}
+
+// messageIndexes returns the path of indexes to a message within its file
+func messageIndexes(messageDesc protoreflect.MessageDescriptor) []int {
+	var indexes []int
+	for desc := protoreflect.Descriptor(messageDesc); ; desc = desc.Parent() {
+		if _, ok := desc.(protoreflect.MessageDescriptor); !ok {
+			break
+		}
+		indexes = append([]int{desc.Index()}, indexes...)
+	}
+	return indexes
+}
@@ -0,0 +1,212 @@
+package format
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Schema types reported by the Schema Registry
+const (
+	SchemaTypeAvro     = "AVRO"
+	SchemaTypeProtobuf = "PROTOBUF"
+)
+
+// wireMagicByte starts every value in the Confluent Schema Registry wire format
+const wireMagicByte = 0
+
+// RegistrySchema is a schema registered in the Schema Registry
+type RegistrySchema struct {
+	ID         int               `json:"id"`
+	Type       string            `json:"schemaType"`
+	Schema     string            `json:"schema"`
+	References []SchemaReference `json:"references"`
+}
+
+// SchemaReference refers to another registered schema imported by a schema
+type SchemaReference struct {
+	Name    string `json:"name"` // Import name, e.g. the .proto file path
+	Subject string `json:"subject"`
+	Version int    `json:"version"`
+}
+
+// SchemaRegistry is a caching client for the Confluent Schema Registry REST API
+type SchemaRegistry struct {
+	baseURL  string
+	username string
+	password string
+	client   *http.Client
+
+	mu        sync.Mutex
+	byID      map[int]*RegistrySchema
+	byRef     map[SchemaReference]*RegistrySchema
+	bySubject map[string]*RegistrySchema // Latest version of each subject
+}
+
+// NewSchemaRegistry creates a client for the registry at the given URL.
+// Credentials in the URL are sent using basic authentication.
+func NewSchemaRegistry(rawURL string) (*SchemaRegistry, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid Schema Registry URL '%s'", rawURL)
+	}
+
+	r := &SchemaRegistry{
+		client:    &http.Client{Timeout: 30 * time.Second},
+		byID:      make(map[int]*RegistrySchema),
+		byRef:     make(map[SchemaReference]*RegistrySchema),
+		bySubject: make(map[string]*RegistrySchema),
+	}
+	if u.User != nil {
+		r.username = u.User.Username()
+		r.password, _ = u.User.Password()
+		u.User = nil
+	}
+	r.baseURL = strings.TrimSuffix(u.String(), "/")
+
+	return r, nil
+}
+
+// SchemaByID returns the schema with the given ID
+func (r *SchemaRegistry) SchemaByID(id int) (*RegistrySchema, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if schema, ok := r.byID[id]; ok {
+		return schema, nil
+	}
+
+	var schema RegistrySchema
+	if err := r.get(fmt.Sprintf("/schemas/ids/%d", id), &schema); err != nil {
+		return nil, fmt.Errorf("failed to fetch schema %d: %w", id, err)
+	}
+	schema.ID = id
+	r.byID[id] = &schema
+
+	return &schema, nil
+}
+
+// LatestSchema returns the latest version of the schema registered under the
+// subject. It is looked up once and then cached.
+func (r *SchemaRegistry) LatestSchema(subject string) (*RegistrySchema, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if schema, ok := r.bySubject[subject]; ok {
+		return schema, nil
+	}
+
+	var schema RegistrySchema
+	if err := r.get(fmt.Sprintf("/subjects/%s/versions/latest", url.PathEscape(subject)), &schema); err != nil {
+		return nil, fmt.Errorf("failed to fetch latest schema of subject %s: %w", subject, err)
+	}
+	r.bySubject[subject] = &schema
+	r.byID[schema.ID] = &schema
+
+	return &schema, nil
+}
+
+// References returns the schemas imported by the schema, directly or through
+// other references, by import name
+func (r *SchemaRegistry) References(schema *RegistrySchema) (map[string]*RegistrySchema, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	references := make(map[string]*RegistrySchema)
+	if err := r.collectReferences(schema.References, references); err != nil {
+		return nil, err
+	}
+	return references, nil
+}
+
+// collectReferences fetches the referenced schemas and their references
+func (r *SchemaRegistry) collectReferences(refs []SchemaReference, references map[string]*RegistrySchema) error {
+	for _, ref := range refs {
+		if _, ok := references[ref.Name]; ok {
+			continue
+		}
+
+		schema, ok := r.byRef[ref]
+		if !ok {
+			schema = &RegistrySchema{}
+			path := fmt.Sprintf("/subjects/%s/versions/%d", url.PathEscape(ref.Subject), ref.Version)
+			if err := r.get(path, schema); err != nil {
+				return fmt.Errorf("failed to fetch referenced schema %s (subject %s version %d): %w", ref.Name, ref.Subject, ref.Version, err)
+			}
+			r.byRef[ref] = schema
+		}
+
+		references[ref.Name] = schema
+		if err := r.collectReferences(schema.References, references); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// get fetches a registry resource and decodes the JSON response into v
+func (r *SchemaRegistry) get(path string, v any) error {
+	req, err := http.NewRequest(http.MethodGet, r.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.schemaregistry.v1+json, application/json")
+	if r.username != "" {
+		req.SetBasicAuth(r.username, r.password)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var registryErr struct {
+			Code    int    `json:"error_code"`
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(body, &registryErr) == nil && registryErr.Message != "" {
+			return fmt.Errorf("registry error %d: %s", registryErr.Code, registryErr.Message)
+		}
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	return json.Unmarshal(body, v)
+}
+
+// schemaType returns the type of a registered schema, which the registry
+// leaves out for Avro schemas
+func (s *RegistrySchema) schemaType() string {
+	if s.Type == "" {
+		return SchemaTypeAvro
+	}
+	return s.Type
+}
+
+// parseWireHeader splits a value in the Schema Registry wire format into its
+// schema ID and the remaining payload
+func parseWireHeader(input []byte) (int, []byte, error) {
+	if len(input) < 5 || input[0] != wireMagicByte {
+		return 0, nil, fmt.Errorf("value is not in Schema Registry wire format (expected magic byte 0 and a 4-byte schema ID)")
+	}
+	return int(binary.BigEndian.Uint32(input[1:5])), input[5:], nil
+}
+
+// appendWireHeader appends the Schema Registry wire format header for the
+// schema ID to buf
+func appendWireHeader(buf []byte, id int) []byte {
+	buf = append(buf, wireMagicByte)
+	return binary.BigEndian.AppendUint32(buf, uint32(id))
+}
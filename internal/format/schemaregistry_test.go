@@ -0,0 +1,260 @@
+package format
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const testCommonProto = `syntax = "proto3";
+
+package common;
+
+message Money {
+  string currency = 1;
+  int64 units = 2;
+}
+`
+
+const testOrdersProto = `syntax = "proto3";
+
+package shop;
+
+import "common.proto";
+
+message Envelope {
+  string source = 1;
+}
+
+message Order {
+  message Line {
+    string sku = 1;
+    common.Money price = 2;
+  }
+
+  string id = 1;
+  repeated Line lines = 2;
+}
+`
+
+// testRegistry is an httptest stand-in for the Schema Registry which counts
+// the requests made for each path
+type testRegistry struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests map[string]int
+}
+
+// newTestRegistry starts a registry serving the test schemas, requiring
+// basic authentication as user:secret
+func newTestRegistry(t *testing.T) *testRegistry {
+	t.Helper()
+
+	orders := map[string]any{
+		"schemaType": "PROTOBUF",
+		"schema":     testOrdersProto,
+		"references": []map[string]any{{"name": "common.proto", "subject": "common-value", "version": 1}},
+	}
+	responses := map[string]any{
+		"/schemas/ids/7": orders,
+		"/subjects/common-value/versions/1": map[string]any{
+			"id": 3, "subject": "common-value", "version": 1, "schemaType": "PROTOBUF", "schema": testCommonProto,
+		},
+		"/subjects/orders-value/versions/latest": map[string]any{
+			"id": 7, "subject": "orders-value", "version": 2, "schemaType": "PROTOBUF", "schema": testOrdersProto,
+			"references": orders["references"],
+		},
+		"/schemas/ids/9": map[string]any{
+			"schema": testUserAvsc,
+		},
+		"/subjects/users-value/versions/latest": map[string]any{
+			"id": 9, "subject": "users-value", "version": 1, "schema": testUserAvsc,
+		},
+	}
+
+	registry := &testRegistry{requests: make(map[string]int)}
+	registry.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		registry.mu.Lock()
+		registry.requests[r.URL.Path]++
+		registry.mu.Unlock()
+
+		if user, pass, ok := r.BasicAuth(); !ok || user != "user" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]any{"error_code": 401, "message": "Unauthorized"})
+			return
+		}
+
+		response, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]any{"error_code": 40403, "message": "Schema not found"})
+			return
+		}
+		w.Header().Set("Content-Type", "application/vnd.schemaregistry.v1+json")
+		json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(registry.Close)
+
+	return registry
+}
+
+// url returns the registry URL with credentials
+func (r *testRegistry) url() string {
+	return strings.Replace(r.URL, "http://", "http://user:secret@", 1)
+}
+
+// requestCount returns the number of requests made for the path
+func (r *testRegistry) requestCount(path string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.requests[path]
+}
+
+func TestRegistryProtoCodecRoundTrip(t *testing.T) {
+	registry := newTestRegistry(t)
+
+	tests := []struct {
+		name           string
+		messageType    string
+		input          string
+		expectedHeader []byte
+	}{
+		{
+			name:           "first message",
+			messageType:    "",
+			input:          `{"source":"checkout"}`,
+			expectedHeader: []byte{0, 0, 0, 0, 7, 0},
+		},
+		{
+			name:           "second message with referenced type",
+			messageType:    "shop.Order",
+			input:          `{"id":"o1","lines":[{"sku":"abc","price":{"currency":"EUR","units":"12"}}]}`,
+			expectedHeader: []byte{0, 0, 0, 0, 7, 2, 2},
+		},
+		{
+			name:           "nested message",
+			messageType:    "shop.Order.Line",
+			input:          `{"sku":"abc"}`,
+			expectedHeader: []byte{0, 0, 0, 0, 7, 4, 2, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codec, err := NewCodecWithSchema("protobuf", SchemaOptions{RegistryURL: registry.url(), Subject: "orders-value", MessageType: tt.messageType})
+			if err != nil {
+				t.Fatalf("Failed to create codec: %v", err)
+			}
+
+			data, err := codec.Decode([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("Failed to decode JSON: %v", err)
+			}
+			if !bytes.HasPrefix(data, tt.expectedHeader) {
+				t.Errorf("Expected wire format header %x, got %x", tt.expectedHeader, data)
+			}
+
+			output, err := codec.Encode(data)
+			if err != nil {
+				t.Fatalf("Failed to encode data: %v", err)
+			}
+			if !jsonEqual(t, output, []byte(tt.input)) {
+				t.Errorf("Expected %s, got %s", tt.input, output)
+			}
+		})
+	}
+}
+
+func TestRegistryProtoCodecCachesSchemas(t *testing.T) {
+	registry := newTestRegistry(t)
+	codec, err := NewRegistryProtoCodec(SchemaOptions{RegistryURL: registry.url()})
+	if err != nil {
+		t.Fatalf("Failed to create codec: %v", err)
+	}
+
+	// Order with id "o1", framed with message indexes [1]
+	value := append([]byte{0, 0, 0, 0, 7, 2, 2}, 0x0a, 0x02, 'o', '1')
+	for range 3 {
+		output, err := codec.Encode(value)
+		if err != nil {
+			t.Fatalf("Failed to encode value: %v", err)
+		}
+		if !jsonEqual(t, output, []byte(`{"id":"o1"}`)) {
+			t.Errorf("Expected {\"id\":\"o1\"}, got %s", output)
+		}
+	}
+
+	if count := registry.requestCount("/schemas/ids/7"); count != 1 {
+		t.Errorf("Expected schema 7 to be fetched once, got %d requests", count)
+	}
+	if count := registry.requestCount("/subjects/common-value/versions/1"); count != 1 {
+		t.Errorf("Expected referenced schema to be fetched once, got %d requests", count)
+	}
+}
+
+func TestRegistryProtoCodecErrors(t *testing.T) {
+	registry := newTestRegistry(t)
+
+	tests := []struct {
+		name  string
+		url   string
+		value []byte
+	}{
+		{"missing magic byte", registry.url(), []byte{1, 0, 0, 0, 7, 0}},
+		{"truncated header", registry.url(), []byte{0, 0, 0}},
+		{"unknown schema", registry.url(), []byte{0, 0, 0, 0, 8, 0}},
+		{"avro schema", registry.url(), []byte{0, 0, 0, 0, 9, 0}},
+		{"message index out of range", registry.url(), []byte{0, 0, 0, 0, 7, 2, 8}},
+		{"invalid message index count", registry.url(), []byte{0, 0, 0, 0, 7, 20, 2}},
+		{"wrong credentials", strings.Replace(registry.url(), "secret", "wrong", 1), []byte{0, 0, 0, 0, 7, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codec, err := NewRegistryProtoCodec(SchemaOptions{RegistryURL: tt.url})
+			if err != nil {
+				t.Fatalf("Failed to create codec: %v", err)
+			}
+			if _, err := codec.Encode(tt.value); err == nil {
+				t.Errorf("Expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestRegistryProtoCodecProduceErrors(t *testing.T) {
+	registry := newTestRegistry(t)
+
+	tests := []struct {
+		name string
+		opts SchemaOptions
+	}{
+		{"no subject", SchemaOptions{RegistryURL: registry.url()}},
+		{"unknown subject", SchemaOptions{RegistryURL: registry.url(), Subject: "payments-value"}},
+		{"unknown message type", SchemaOptions{RegistryURL: registry.url(), Subject: "orders-value", MessageType: "shop.Refund"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codec, err := NewRegistryProtoCodec(tt.opts)
+			if err != nil {
+				t.Fatalf("Failed to create codec: %v", err)
+			}
+			if _, err := codec.Decode([]byte(`{}`)); err == nil {
+				t.Errorf("Expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestNewSchemaRegistryInvalidURL(t *testing.T) {
+	for _, rawURL := range []string{"", "localhost:8081", "http://"} {
+		if _, err := NewSchemaRegistry(rawURL); err == nil {
+			t.Errorf("Expected error for URL '%s', got nil", rawURL)
+		}
+	}
+}
@@ -2,7 +2,10 @@ package producer
 
 import (
 	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"sync"
@@ -14,28 +17,79 @@ import (
 
 // Producer handles Kafka message production
 type Producer struct {
-	client *kgo.Client
-	topic  string
-	codec  format.Codec
+	client       *kgo.Client
+	topic        string
+	keyCodec     format.Codec
+	valueCodec   format.Codec
+	headerCodec  format.Codec            // Default codec for per-line header values
+	headerCodecs map[string]format.Codec // Per-header-name codec overrides
+	headers      []kgo.RecordHeader      // Headers added to every record
+	keyDelimiter []byte                  // Separates key from value on each input line, nil for no keys
+	jsonInput    bool                    // Each input line is a JSON object with key, value and headers
+}
+
+// jsonRecord is the JSON input representation of a record, matching the
+// envelope printed by the consumer in JSON mode
+type jsonRecord struct {
+	Key     json.RawMessage `json:"key"`
+	Value   json.RawMessage `json:"value"`
+	Headers []struct {
+		Key   string          `json:"key"`
+		Value json.RawMessage `json:"value"`
+	} `json:"headers"`
 }
 
 // New creates a new Producer instance
-func New(client *kgo.Client, topic string, formatStr config.Format) (*Producer, error) {
-	codec, err := format.NewCodec(string(formatStr))
+func New(client *kgo.Client, cfg *config.Config) (*Producer, error) {
+	keyCodec, err := format.NewCodec(string(cfg.KeyFormat))
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to initialize key codec: %w", err)
 	}
 
-	return &Producer{
-		client: client,
-		topic:  topic,
-		codec:  codec,
-	}, nil
+	valueFormat := format.WithCompression(string(cfg.ValueFormat), cfg.Compression)
+	valueCodec, err := format.NewCodecWithSchema(valueFormat, cfg.SchemaOptions())
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize value codec: %w", err)
+	}
+
+	headerCodec, err := format.NewCodec(string(cfg.HeaderFormat))
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize header codec: %w", err)
+	}
+
+	headerCodecs := make(map[string]format.Codec, len(cfg.HeaderFormats))
+	for name, headerFormat := range cfg.HeaderFormats {
+		headerCodecs[name], err = format.NewCodec(string(headerFormat))
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize codec for header %s: %w", name, err)
+		}
+	}
+
+	p := &Producer{
+		client:       client,
+		topic:        cfg.Topics[0],
+		keyCodec:     keyCodec,
+		valueCodec:   valueCodec,
+		headerCodec:  headerCodec,
+		headerCodecs: headerCodecs,
+		headers:      cfg.Headers,
+		jsonInput:    cfg.JSONMode,
+	}
+	if cfg.KeyDelimiter != "" {
+		p.keyDelimiter = []byte(cfg.KeyDelimiter)
+	}
+
+	return p, nil
 }
 
 // Run starts the producer reading from stdin
 func (p *Producer) Run(ctx context.Context, wg *sync.WaitGroup) {
 	defer wg.Done()
+	defer func() {
+		if err := p.close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error closing codecs: %v\n", err)
+		}
+	}()
 
 	scanner := bufio.NewScanner(os.Stdin)
 	for scanner.Scan() {
@@ -43,18 +97,12 @@ func (p *Producer) Run(ctx context.Context, wg *sync.WaitGroup) {
 		case <-ctx.Done():
 			return
 		default:
-			input := scanner.Bytes()
-			value, err := p.codec.Decode(input)
+			record, err := p.buildRecord(scanner.Bytes())
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Failed to decode input: %v\n", err)
 				continue
 			}
 
-			record := &kgo.Record{
-				Topic: p.topic,
-				Value: value,
-			}
-
 			results := p.client.ProduceSync(ctx, record)
 			if results.FirstErr() != nil {
 				fmt.Fprintf(os.Stderr, "Failed to produce message: %v\n", results.FirstErr())
@@ -66,3 +114,114 @@ func (p *Producer) Run(ctx context.Context, wg *sync.WaitGroup) {
 		fmt.Fprintf(os.Stderr, "Error reading from stdin: %v\n", err)
 	}
 }
+
+// buildRecord creates a record from a single line of input. When a key
+// delimiter is configured, the line is split at its first occurrence into key
+// and value; lines without the delimiter are produced with a nil key.
+func (p *Producer) buildRecord(input []byte) (*kgo.Record, error) {
+	if p.jsonInput {
+		return p.buildJSONRecord(input)
+	}
+
+	record := &kgo.Record{
+		Topic:   p.topic,
+		Headers: p.recordHeaders(),
+	}
+
+	valueInput := input
+	if p.keyDelimiter != nil {
+		if keyInput, rest, found := bytes.Cut(input, p.keyDelimiter); found {
+			key, err := p.keyCodec.Decode(keyInput)
+			if err != nil {
+				return nil, fmt.Errorf("key: %w", err)
+			}
+			record.Key = key
+			valueInput = rest
+		}
+	}
+
+	value, err := p.valueCodec.Decode(valueInput)
+	if err != nil {
+		return nil, err
+	}
+	record.Value = value
+
+	return record, nil
+}
+
+// buildJSONRecord creates a record from a JSON object with optional key, value
+// and headers fields. String fields are decoded through their codec; other
+// JSON values, and any value of a codec reading JSON, are passed to the codec
+// as JSON documents.
+func (p *Producer) buildJSONRecord(input []byte) (*kgo.Record, error) {
+	var in jsonRecord
+	if err := json.Unmarshal(input, &in); err != nil {
+		return nil, fmt.Errorf("invalid JSON input: %w", err)
+	}
+
+	key, err := decodeJSONField(p.keyCodec, in.Key)
+	if err != nil {
+		return nil, fmt.Errorf("key: %w", err)
+	}
+
+	value, err := decodeJSONField(p.valueCodec, in.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := p.recordHeaders()
+	for _, header := range in.Headers {
+		codec := p.headerCodec
+		if c, ok := p.headerCodecs[header.Key]; ok {
+			codec = c
+		}
+		headerValue, err := decodeJSONField(codec, header.Value)
+		if err != nil {
+			return nil, fmt.Errorf("header %s: %w", header.Key, err)
+		}
+		headers = append(headers, kgo.RecordHeader{Key: header.Key, Value: headerValue})
+	}
+
+	return &kgo.Record{
+		Topic:   p.topic,
+		Key:     key,
+		Value:   value,
+		Headers: headers,
+	}, nil
+}
+
+// close releases the resources held by the codecs
+func (p *Producer) close() error {
+	errs := []error{format.CloseCodec(p.keyCodec), format.CloseCodec(p.valueCodec), format.CloseCodec(p.headerCodec)}
+	for _, codec := range p.headerCodecs {
+		errs = append(errs, format.CloseCodec(codec))
+	}
+	return errors.Join(errs...)
+}
+
+// recordHeaders returns a copy of the headers configured for every record
+func (p *Producer) recordHeaders() []kgo.RecordHeader {
+	if len(p.headers) == 0 {
+		return nil
+	}
+	return append([]kgo.RecordHeader(nil), p.headers...)
+}
+
+// decodeJSONField decodes a JSON field through the codec, treating a missing
+// field or null as a nil value. Strings are unwrapped unless the codec reads
+// JSON, matching how the consumer embeds values in its JSON output.
+func decodeJSONField(codec format.Codec, raw json.RawMessage) ([]byte, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	if raw[0] == '"' && !format.EncodesJSON(codec) {
+		var str string
+		if err := json.Unmarshal(raw, &str); err != nil {
+			return nil, err
+		}
+		return codec.Decode([]byte(str))
+	}
+
+	return codec.Decode(raw)
+}
@@ -0,0 +1,232 @@
+package producer
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/jarkkom/kafkadog/internal/config"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// TestBuildRecord tests splitting input lines into record keys and values
+func TestBuildRecord(t *testing.T) {
+	tests := []struct {
+		name          string
+		format        string
+		keyDelimiter  string
+		input         string
+		expectedKey   []byte
+		expectedValue []byte
+		expectError   bool
+	}{
+		{
+			name:          "no delimiter configured",
+			format:        "raw",
+			input:         "user-1:hello",
+			expectedKey:   nil,
+			expectedValue: []byte("user-1:hello"),
+		},
+		{
+			name:          "split at first delimiter",
+			format:        "raw",
+			keyDelimiter:  ":",
+			input:         "user-1:hello:world",
+			expectedKey:   []byte("user-1"),
+			expectedValue: []byte("hello:world"),
+		},
+		{
+			name:          "multi-character delimiter",
+			format:        "raw",
+			keyDelimiter:  "::",
+			input:         "a:b::c",
+			expectedKey:   []byte("a:b"),
+			expectedValue: []byte("c"),
+		},
+		{
+			name:          "line without delimiter has nil key",
+			format:        "raw",
+			keyDelimiter:  "\t",
+			input:         "hello",
+			expectedKey:   nil,
+			expectedValue: []byte("hello"),
+		},
+		{
+			name:          "empty key",
+			format:        "raw",
+			keyDelimiter:  ":",
+			input:         ":hello",
+			expectedKey:   []byte{},
+			expectedValue: []byte("hello"),
+		},
+		{
+			name:          "key and value pass through codec",
+			format:        "hex",
+			keyDelimiter:  " ",
+			input:         "0102 ff",
+			expectedKey:   []byte{0x01, 0x02},
+			expectedValue: []byte{0xff},
+		},
+		{
+			name:         "invalid key for codec",
+			format:       "hex",
+			keyDelimiter: " ",
+			input:        "zz ff",
+			expectError:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				Topics:       []string{"test-topic"},
+				KeyFormat:    config.Format(tt.format),
+				ValueFormat:  config.Format(tt.format),
+				HeaderFormat: "raw",
+				KeyDelimiter: tt.keyDelimiter,
+			}
+			p, err := New(nil, cfg)
+			if err != nil {
+				t.Fatalf("Failed to create producer: %v", err)
+			}
+
+			record, err := p.buildRecord([]byte(tt.input))
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			if record.Topic != "test-topic" {
+				t.Errorf("Expected Topic='test-topic', got '%s'", record.Topic)
+			}
+			if (tt.expectedKey == nil) != (record.Key == nil) || !bytes.Equal(record.Key, tt.expectedKey) {
+				t.Errorf("Expected key %q, got %q", tt.expectedKey, record.Key)
+			}
+			if !bytes.Equal(record.Value, tt.expectedValue) {
+				t.Errorf("Expected value %q, got %q", tt.expectedValue, record.Value)
+			}
+		})
+	}
+}
+
+// TestBuildRecordHeaders tests static headers and JSON input records
+func TestBuildRecordHeaders(t *testing.T) {
+	cfg := &config.Config{
+		Topics:        []string{"test-topic"},
+		KeyFormat:     "raw",
+		ValueFormat:   "hex",
+		HeaderFormat:  "raw",
+		HeaderFormats: map[string]config.Format{"id": "hex"},
+		Headers:       []kgo.RecordHeader{{Key: "source", Value: []byte("cli")}},
+	}
+
+	t.Run("static headers on plain input", func(t *testing.T) {
+		p, err := New(nil, cfg)
+		if err != nil {
+			t.Fatalf("Failed to create producer: %v", err)
+		}
+
+		record, err := p.buildRecord([]byte("ff"))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(record.Headers) != 1 || record.Headers[0].Key != "source" || string(record.Headers[0].Value) != "cli" {
+			t.Errorf("Expected headers [source=cli], got %v", record.Headers)
+		}
+	})
+
+	t.Run("json input", func(t *testing.T) {
+		jsonCfg := *cfg
+		jsonCfg.JSONMode = true
+		p, err := New(nil, &jsonCfg)
+		if err != nil {
+			t.Fatalf("Failed to create producer: %v", err)
+		}
+
+		input := `{"topic":"ignored","key":"user-1","value":"cafe","headers":[{"key":"traceparent","value":"00-abc-01"},{"key":"id","value":"0102"}]}`
+		record, err := p.buildRecord([]byte(input))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if record.Topic != "test-topic" {
+			t.Errorf("Expected Topic='test-topic', got '%s'", record.Topic)
+		}
+		if string(record.Key) != "user-1" {
+			t.Errorf("Expected key 'user-1', got %q", record.Key)
+		}
+		if !bytes.Equal(record.Value, []byte{0xca, 0xfe}) {
+			t.Errorf("Expected value cafe, got %x", record.Value)
+		}
+
+		expected := []kgo.RecordHeader{
+			{Key: "source", Value: []byte("cli")},
+			{Key: "traceparent", Value: []byte("00-abc-01")},
+			{Key: "id", Value: []byte{0x01, 0x02}},
+		}
+		if len(record.Headers) != len(expected) {
+			t.Fatalf("Expected %d headers, got %d", len(expected), len(record.Headers))
+		}
+		for i, header := range expected {
+			if record.Headers[i].Key != header.Key || !bytes.Equal(record.Headers[i].Value, header.Value) {
+				t.Errorf("Expected header %s=%q at position %d, got %s=%q", header.Key, header.Value, i, record.Headers[i].Key, record.Headers[i].Value)
+			}
+		}
+	})
+
+	t.Run("json input with null key", func(t *testing.T) {
+		jsonCfg := *cfg
+		jsonCfg.JSONMode = true
+		p, err := New(nil, &jsonCfg)
+		if err != nil {
+			t.Fatalf("Failed to create producer: %v", err)
+		}
+
+		record, err := p.buildRecord([]byte(`{"key":null,"value":"00"}`))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if record.Key != nil {
+			t.Errorf("Expected nil key, got %q", record.Key)
+		}
+	})
+
+	t.Run("json input with JSON string values", func(t *testing.T) {
+		jsonCfg := *cfg
+		jsonCfg.JSONMode = true
+		jsonCfg.ValueFormat = "json"
+		p, err := New(nil, &jsonCfg)
+		if err != nil {
+			t.Fatalf("Failed to create producer: %v", err)
+		}
+
+		// A string value as printed by the consumer for a JSON codec
+		record, err := p.buildRecord([]byte(`{"key":"user-1","value":"abc"}`))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if string(record.Key) != "user-1" {
+			t.Errorf("Expected key 'user-1', got %q", record.Key)
+		}
+		if string(record.Value) != `"abc"` {
+			t.Errorf("Expected value \"abc\", got %q", record.Value)
+		}
+	})
+
+	t.Run("invalid json input", func(t *testing.T) {
+		jsonCfg := *cfg
+		jsonCfg.JSONMode = true
+		p, err := New(nil, &jsonCfg)
+		if err != nil {
+			t.Fatalf("Failed to create producer: %v", err)
+		}
+
+		if _, err := p.buildRecord([]byte(`not json`)); err == nil {
+			t.Errorf("Expected error, got nil")
+		}
+	})
+}